@@ -0,0 +1,166 @@
+package scraper
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+)
+
+// ArchiveGetter is a Getter that serves urls from the files
+// in a zip or tar archive of a site, mapping each url's path
+// to the entry of the same name (ignoring its host and query)
+// and serving index.html for directory urls. The archive is
+// read once when opened, so one ArchiveGetter can be shared
+// by any number of scrapes until it is closed.
+type ArchiveGetter struct {
+	// Root is the directory in the archive
+	// holding the site, if it is not at the top
+	Root string
+
+	entries map[string]func() (io.ReadCloser, error)
+	closer  io.Closer
+}
+
+// OpenZip creates an ArchiveGetter serving
+// the files in the zip archive at path
+func OpenZip(path string) (*ArchiveGetter, error) {
+
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &ArchiveGetter{
+		entries: make(map[string]func() (io.ReadCloser, error)),
+		closer:  zr,
+	}
+	for _, f := range zr.File {
+		if !strings.HasSuffix(f.Name, "/") {
+			g.entries[archiveName(f.Name)] = f.Open
+		}
+	}
+
+	return g, nil
+}
+
+// OpenTarGz creates an ArchiveGetter serving the files in the
+// tar archive at path, which may be gzipped. Unlike zip files,
+// tar archives can't be read at random so are held in memory,
+// with the limits' MaxBodyBytes capping each file and MaxRatio
+// capping the whole archive once it is decompressed.
+func OpenTarGz(path string, limits Limits) (*ArchiveGetter, error) {
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	compressed := &countingReader{Reader: file}
+	r, _, err := decompress(compressed, "")
+	if err != nil {
+		return nil, err
+	}
+	if limits.MaxRatio > 0 {
+		r = &ratioReader{r, compressed, 0, &LimitError{path, "MaxRatio", 0, limits.MaxRatio}}
+	}
+
+	g := &ArchiveGetter{
+		entries: make(map[string]func() (io.ReadCloser, error)),
+	}
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return g, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		entry := io.Reader(tr)
+		if max := limits.MaxBodyBytes; max > 0 {
+			entry = &bodyLimitReader{tr, 0, &LimitError{header.Name, "MaxBodyBytes", max, 0}}
+		}
+		data, err := ioutil.ReadAll(entry)
+		if err != nil {
+			return nil, err
+		}
+		g.entries[archiveName(header.Name)] = func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(bytes.NewReader(data)), nil
+		}
+	}
+}
+
+// Get retrieves the archived file for the url
+func (g *ArchiveGetter) Get(url string, srcURL string) (io.ReadCloser, error) {
+	return g.GetContext(context.Background(), url, srcURL)
+}
+
+// GetContext retrieves the archived file for the
+// url, failing if the context is already done
+func (g *ArchiveGetter) GetContext(ctx context.Context, urlStr string, srcURL string) (io.ReadCloser, error) {
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	resolved, err := resolveURL(urlStr, srcURL)
+	if err != nil {
+		return nil, err
+	}
+	uri, err := url.Parse(resolved)
+	if err != nil {
+		return nil, err
+	}
+
+	name := archiveName(path.Join(g.Root, path.Clean("/"+uri.Path)))
+	if strings.HasSuffix(uri.Path, "/") || uri.Path == "" {
+		name = path.Join(name, "index.html")
+	}
+
+	open := g.entries[name]
+	if open == nil {
+		// A url without a trailing slash may still be a directory
+		open = g.entries[path.Join(name, "index.html")]
+	}
+	if open == nil {
+		return nil, &FixtureError{resolved, name}
+	}
+
+	rc, err := open()
+	if err != nil {
+		return nil, err
+	}
+	// Zip entries are only decompressed here,
+	// so are held to the scrape's MaxBodyBytes
+	return struct {
+		io.Reader
+		io.Closer
+	}{limitBody(ctx, resolved, rc), rc}, nil
+}
+
+// Close closes the archive, after which
+// the getter can no longer be used
+func (g *ArchiveGetter) Close() error {
+	if g.closer == nil {
+		return nil
+	}
+	return g.closer.Close()
+}
+
+// archiveName cleans an entry name, such as "./site/a.html",
+// so it matches the names looked up for urls, "site/a.html"
+func archiveName(name string) string {
+	return strings.TrimPrefix(path.Clean("/"+name), "/")
+}
@@ -0,0 +1,160 @@
+package scraper
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var archiveFiles = map[string]string{
+	"snapshot/index.html":       `<a href="docs/">Docs</a><a href="/about">About</a><a href="/missing">Missing</a>`,
+	"snapshot/docs/index.html":  `<p>Docs</p>`,
+	"snapshot/about/index.html": `<p>About</p>`,
+}
+
+func writeTestZip(t *testing.T) string {
+
+	path := filepath.Join(t.TempDir(), "site.zip")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	zw := zip.NewWriter(file)
+	for name, data := range archiveFiles {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(data))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func writeTestTarGz(t *testing.T, files map[string]string) string {
+
+	path := filepath.Join(t.TempDir(), "site.tar.gz")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	gw := gzip.NewWriter(file)
+	tw := tar.NewWriter(gw)
+	for name, data := range files {
+		err := tw.WriteHeader(&tar.Header{
+			Name: "./" + name, Mode: 0644, Size: int64(len(data)),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		tw.Write([]byte(data))
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestArchiveGetter(t *testing.T) {
+
+	open := map[string]func(string) (*ArchiveGetter, error){
+		"zip": OpenZip,
+		"tar.gz": func(path string) (*ArchiveGetter, error) {
+			return OpenTarGz(path, Limits{})
+		},
+	}
+	paths := map[string]string{
+		"zip":    writeTestZip(t),
+		"tar.gz": writeTestTarGz(t, archiveFiles),
+	}
+
+	expected := []map[string]string{
+		{"value": "Docs"},
+		{"value": "About"},
+	}
+
+	for kind, openArchive := range open {
+
+		getter, err := openArchive(paths[kind])
+		if err != nil {
+			t.Fatalf("%v: %v", kind, err)
+		}
+		getter.Root = "snapshot"
+
+		// The archive is reused by every scrape
+		for i := 0; i < 2; i++ {
+			results, err := New("http://example.com", nil, getter).
+				Follow("a[href]").
+				Select(Sel{"value": "p"}).
+				Done()
+			if err != nil {
+				t.Fatalf("%v: %v", kind, err)
+			}
+			if !reflect.DeepEqual(results, expected) {
+				t.Fatalf("%v: Expected %v, received %v", kind, expected, results)
+			}
+		}
+
+		_, err = getter.Get("http://example.com/missing", "")
+		var fixtureErr *FixtureError
+		if !errors.As(err, &fixtureErr) || fixtureErr.Path != "snapshot/missing" {
+			t.Errorf("%v: Expected a FixtureError for a missing entry, received %v", kind, err)
+		}
+		if err := getter.Close(); err != nil {
+			t.Errorf("%v: %v", kind, err)
+		}
+	}
+}
+
+func TestArchiveGetter_Limits(t *testing.T) {
+
+	// Each file of a tar archive is capped as it is read in
+	_, err := OpenTarGz(writeTestTarGz(t, archiveFiles), Limits{MaxBodyBytes: 20})
+	var limitErr *LimitError
+	if !errors.As(err, &limitErr) || limitErr.Limit != "MaxBodyBytes" {
+		t.Fatalf("Expected a MaxBodyBytes LimitError, received %v", err)
+	}
+
+	// As is the whole archive once decompressed
+	bomb := map[string]string{"zeros": strings.Repeat("0", 1<<20)}
+	_, err = OpenTarGz(writeTestTarGz(t, bomb), Limits{MaxRatio: 10})
+	if !errors.As(err, &limitErr) || limitErr.Limit != "MaxRatio" {
+		t.Fatalf("Expected a MaxRatio LimitError, received %v", err)
+	}
+
+	// Zip files are capped by the scrape's limits as they are got
+	getter, err := OpenZip(writeTestZip(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer getter.Close()
+	getter.Root = "snapshot"
+
+	ctx := withLimits(context.Background(), Limits{MaxBodyBytes: 20})
+	rc, err := getter.GetContext(ctx, "http://example.com/", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	_, err = ioutil.ReadAll(rc)
+	if !errors.As(err, &limitErr) || limitErr.Limit != "MaxBodyBytes" {
+		t.Fatalf("Expected a MaxBodyBytes LimitError, received %v", err)
+	}
+}
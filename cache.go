@@ -0,0 +1,240 @@
+package scraper
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ErrNotCached is returned by an offline CachingGetter
+// for urls that have no cached response
+var ErrNotCached = errors.New("No cached response")
+
+// CachingGetter is a Getter that stores the responses of
+// the Getter it wraps on disk, keyed by their canonical url.
+// Stale responses are revalidated using their ETag or
+// Last-Modified headers when the server sent them.
+type CachingGetter struct {
+	Getter
+	// Dir is the directory the responses are stored in
+	Dir string
+	// TTL is how long a stored response is used without
+	// revalidating it, zero to revalidate every time
+	TTL time.Duration
+	// Offline serves every url from the cache, however old,
+	// failing with ErrNotCached instead of making requests
+	Offline bool
+	// Canonicalizer decides which urls share a cached response,
+	// defaulting to the one used by CanonicalURL. Responses are
+	// stored before being parsed, so a page's <link
+	// rel="canonical"> is not used.
+	Canonicalizer *Canonicalizer
+}
+
+type cacheEntry struct {
+	URL    string
+	Status int
+	Header http.Header
+	Stored time.Time
+}
+
+// Get retrieves the url from the cache when possible
+func (g *CachingGetter) Get(url string, srcURL string) (io.ReadCloser, error) {
+	return g.GetContext(context.Background(), url, srcURL)
+}
+
+// GetContext retrieves the url from the cache when possible,
+// otherwise getting and storing it with the wrapped Getter
+func (g *CachingGetter) GetContext(ctx context.Context, url string, srcURL string) (io.ReadCloser, error) {
+
+	// Only plain GETs are safe to answer from the cache
+	if method := requestFrom(ctx).Method; method != "" && method != http.MethodGet {
+		return getContext(ctx, g.Getter, url, srcURL)
+	}
+
+	resolved, err := resolveURL(url, srcURL)
+	if err != nil {
+		return nil, err
+	}
+
+	key := g.key(g.Canonicalizer.key(resolved))
+	entry, body, err := g.load(key)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	if entry == nil {
+		if g.Offline {
+			return nil, fmt.Errorf("%w for url %q", ErrNotCached, resolved)
+		}
+		return g.fetch(ctx, key, resolved, url, srcURL, nil)
+	}
+
+	if g.Offline || time.Since(entry.Stored) < g.TTL {
+		return entry.response(body), nil
+	}
+
+	return g.fetch(ctx, key, resolved, url, srcURL, entry)
+}
+
+// fetch gets the url with the wrapped Getter, asking the server
+// to only send the data if it has changed from the stored entry
+func (g *CachingGetter) fetch(ctx context.Context, key string,
+	resolved string, url string, srcURL string, stored *cacheEntry) (io.ReadCloser, error) {
+
+	if stored != nil {
+		header := http.Header{}
+		if etag := stored.Header.Get("ETag"); etag != "" {
+			header.Set("If-None-Match", etag)
+		}
+		if modified := stored.Header.Get("Last-Modified"); modified != "" {
+			header.Set("If-Modified-Since", modified)
+		}
+		ctx = withRequestHeader(ctx, header)
+	}
+
+	rc, err := getContext(ctx, g.Getter, url, srcURL)
+
+	var statusErr *HTTPStatusError
+	if stored != nil && errors.As(err, &statusErr) &&
+		statusErr.StatusCode == http.StatusNotModified {
+		return g.revalidated(key, stored, statusErr.Header)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	defer rc.Close()
+	status := http.StatusOK
+	if resp, ok := rc.(Response); ok {
+		status = resp.StatusCode()
+		if stored != nil && status == http.StatusNotModified {
+			return g.revalidated(key, stored, resp.Header())
+		}
+	}
+
+	body, err := ioutil.ReadAll(limitBody(ctx, resolved, rc))
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &cacheEntry{resolved, status, responseHeader(rc), time.Now()}
+	if err := g.store(key, entry, body); err != nil {
+		return nil, err
+	}
+
+	return entry.response(body), nil
+}
+
+// revalidated restarts the TTL of a stored entry that the
+// server says is unchanged, updating its headers with those
+// of the 304 so later revalidations send the new validators
+func (g *CachingGetter) revalidated(key string, entry *cacheEntry, header http.Header) (io.ReadCloser, error) {
+
+	body, err := ioutil.ReadFile(g.path(key, ".body"))
+	if err != nil {
+		return nil, err
+	}
+
+	if entry.Header == nil {
+		entry.Header = http.Header{}
+	}
+	for name, values := range header {
+		// The 304 has no body, so its length isn't the stored one's
+		if name != "Content-Length" {
+			entry.Header[name] = values
+		}
+	}
+	entry.Stored = time.Now()
+	if err := g.store(key, entry, nil); err != nil {
+		return nil, err
+	}
+
+	return entry.response(body), nil
+}
+
+func (g *CachingGetter) key(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return hex.EncodeToString(sum[:])
+}
+
+func (g *CachingGetter) path(key string, ext string) string {
+	return filepath.Join(g.Dir, key+ext)
+}
+
+func (g *CachingGetter) load(key string) (*cacheEntry, []byte, error) {
+
+	data, err := ioutil.ReadFile(g.path(key, ".json"))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	entry := &cacheEntry{}
+	if err := json.Unmarshal(data, entry); err != nil {
+		return nil, nil, err
+	}
+
+	body, err := ioutil.ReadFile(g.path(key, ".body"))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return entry, body, nil
+}
+
+// store writes the entry, along with its body unless it is
+// nil, replacing the files so readers never see partial data
+func (g *CachingGetter) store(key string, entry *cacheEntry, body []byte) error {
+
+	if err := os.MkdirAll(g.Dir, 0755); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	if body != nil {
+		if err := writeFile(g.path(key, ".body"), body); err != nil {
+			return err
+		}
+	}
+	return writeFile(g.path(key, ".json"), data)
+}
+
+// writeFile atomically replaces the file at
+// path by renaming a temp file over it
+func writeFile(path string, data []byte) error {
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+func (e *cacheEntry) response(body []byte) io.ReadCloser {
+	return &memoryResponse{bytes.NewReader(body), e.Status, e.Header}
+}
@@ -0,0 +1,128 @@
+package scraper
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCachingGetter_TTL(t *testing.T) {
+
+	var requests []*http.Request
+	server := newTestServer(testRoutes{
+		"/page": func(w http.ResponseWriter, r *http.Request) {
+			requests = append(requests, r)
+			io.WriteString(w, "plain page")
+		},
+	})
+	defer server.Close()
+
+	dir := t.TempDir()
+
+	getter := &CachingGetter{Getter: HTTPGetter(), Dir: dir, TTL: time.Hour}
+	verifyGetter(t, getter, server.URL+"/page", "plain page")
+	verifyGetter(t, getter, server.URL+"/page", "plain page")
+
+	// Responses are keyed by resolved url, so relative urls hit too
+	getter = &CachingGetter{Getter: HTTPGetter(), Dir: dir, TTL: time.Hour}
+	rc, err := getter.Get("/page", server.URL+"/other")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rc.Close()
+
+	if len(requests) != 1 {
+		t.Fatalf("Expected 1 request, received %v", len(requests))
+	}
+}
+
+func TestCachingGetter_Revalidate(t *testing.T) {
+
+	var requests []*http.Request
+	server := newTestServer(testRoutes{
+		"/etag": func(w http.ResponseWriter, r *http.Request) {
+			requests = append(requests, r)
+			w.Header().Set("ETag", `"v1"`)
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			io.WriteString(w, "etag page")
+		},
+	})
+	defer server.Close()
+
+	dir := t.TempDir()
+
+	getter := &CachingGetter{Getter: &RetryGetter{Getter: HTTPGetter()}, Dir: dir}
+	verifyGetter(t, getter, server.URL+"/etag", "etag page")
+	verifyGetter(t, getter, server.URL+"/etag", "etag page")
+
+	if len(requests) != 2 {
+		t.Fatalf("Expected 2 requests, received %v", len(requests))
+	}
+	if etag := requests[1].Header.Get("If-None-Match"); etag != `"v1"` {
+		t.Fatalf("Expected an If-None-Match header, received %q", etag)
+	}
+
+	rc, err := getter.Get(server.URL+"/etag", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if etag := responseHeader(rc).Get("ETag"); etag != `"v1"` {
+		t.Fatalf("Expected the stored headers, received %q", etag)
+	}
+}
+
+func TestCachingGetter_Offline(t *testing.T) {
+
+	dir := t.TempDir()
+
+	getter := &CachingGetter{
+		Getter: MemoryGetter{"http://localhost/": "page"},
+		Dir:    dir,
+	}
+	verifyGetter(t, getter, "http://localhost/", "page")
+
+	offline := &CachingGetter{Getter: MemoryGetter{}, Dir: dir, Offline: true}
+	verifyGetter(t, offline, "http://localhost/", "page")
+
+	_, err := offline.Get("http://localhost/missing", "")
+	if !errors.Is(err, ErrNotCached) {
+		t.Fatalf("Expected %q, received %v", ErrNotCached, err)
+	}
+}
+
+func TestCachingGetter_RevalidateHeaders(t *testing.T) {
+
+	var validators []string
+	server := newTestServer(testRoutes{
+		"/": func(w http.ResponseWriter, r *http.Request) {
+			etag := r.Header.Get("If-None-Match")
+			validators = append(validators, etag)
+			if etag == "" {
+				w.Header().Set("ETag", `"v1"`)
+				io.WriteString(w, "page")
+				return
+			}
+			// The server moves to a new validator
+			// without the page changing
+			w.Header().Set("ETag", `"v2"`)
+			w.WriteHeader(http.StatusNotModified)
+		},
+	})
+	defer server.Close()
+
+	getter := &CachingGetter{Getter: HTTPGetter(), Dir: t.TempDir()}
+	for i := 0; i < 3; i++ {
+		verifyGetter(t, getter, server.URL+"/", "page")
+	}
+
+	expected := []string{"", `"v1"`, `"v2"`}
+	if !reflect.DeepEqual(validators, expected) {
+		t.Fatalf("Expected validators %q, received %q", expected, validators)
+	}
+}
@@ -0,0 +1,170 @@
+package scraper
+
+import (
+	"net/url"
+	"strings"
+
+	css "github.com/andybalholm/cascadia"
+	"golang.org/x/net/html"
+)
+
+// TrackingParams are the query parameters removed by
+// the default Canonicalizer, as they only track visits
+var TrackingParams = []string{
+	"utm_*", "gclid", "dclid", "fbclid", "msclkid",
+	"mc_cid", "mc_eid", "yclid", "igshid", "_ga",
+}
+
+// Canonicalizer turns urls into a canonical form, so that
+// urls for the same page are only fetched and cached once.
+// Scheme and host are lowercased, default ports, dot segments
+// and fragments removed, and the query parameters sorted. The
+// path keeps its escapes, with their hex digits uppercased,
+// except those of unreserved characters which are decoded, so
+// /a%2Fb and /a/b are still different pages.
+// A page's <link rel="canonical"> is only known once it is
+// parsed, so is applied by a scrape's Crawl and the pages
+// it keeps, but not by the CachingGetter.
+type Canonicalizer struct {
+	// StripParams lists the query parameters removed, where
+	// a trailing * matches any parameter with that prefix
+	StripParams []string
+}
+
+var defaultCanonicalizer = &Canonicalizer{TrackingParams}
+
+var canonicalLink = css.MustCompile(`link[rel~="canonical"][href]`)
+
+// CanonicalURL returns the canonical form of the url,
+// removing the TrackingParams from its query
+func CanonicalURL(urlStr string) (string, error) {
+	return defaultCanonicalizer.Canonical(urlStr)
+}
+
+// Canonical returns the canonical form of the url
+func (c *Canonicalizer) Canonical(urlStr string) (string, error) {
+
+	uri, err := url.Parse(urlStr)
+	if err != nil {
+		return "", err
+	}
+
+	uri.Scheme = strings.ToLower(uri.Scheme)
+	uri.Host = strings.ToLower(uri.Host)
+	if port := uri.Port(); (uri.Scheme == "http" && port == "80") ||
+		(uri.Scheme == "https" && port == "443") {
+		uri.Host = uri.Hostname()
+	}
+
+	escaped := normalizeEscapes(uri.EscapedPath())
+	if uri.Path, err = url.PathUnescape(escaped); err != nil {
+		return "", err
+	}
+	uri.RawPath = escaped
+
+	// Resolving against nothing removes dot segments
+	uri = uri.ResolveReference(&url.URL{})
+	if uri.Path == "" && uri.Host != "" {
+		uri.Path = "/"
+	}
+	uri.Fragment, uri.RawFragment = "", ""
+
+	query := uri.Query()
+	for name := range query {
+		if c.strip(name) {
+			delete(query, name)
+		}
+	}
+	// Encode sorts the parameters by name
+	uri.RawQuery = query.Encode()
+	uri.ForceQuery = false
+
+	return uri.String(), nil
+}
+
+// normalizeEscapes uppercases the hex digits of the escapes
+// in the path and decodes those of unreserved characters
+func normalizeEscapes(path string) string {
+
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		if path[i] != '%' || i+2 >= len(path) ||
+			!isHex(path[i+1]) || !isHex(path[i+2]) {
+			b.WriteByte(path[i])
+			continue
+		}
+		c := unhex(path[i+1])<<4 | unhex(path[i+2])
+		if isUnreserved(c) {
+			b.WriteByte(c)
+		} else {
+			b.WriteString(strings.ToUpper(path[i : i+3]))
+		}
+		i += 2
+	}
+	return b.String()
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+func unhex(c byte) byte {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0'
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10
+	}
+	return c - 'A' + 10
+}
+
+// isUnreserved reports whether the character
+// never needs escaping in a url (RFC 3986 2.3)
+func isUnreserved(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' ||
+		'0' <= c && c <= '9' || c == '-' || c == '.' || c == '_' || c == '~'
+}
+
+// strip reports whether the query parameter is removed
+func (c *Canonicalizer) strip(name string) bool {
+
+	name = strings.ToLower(name)
+	for _, param := range c.StripParams {
+		param = strings.ToLower(param)
+		if strings.HasSuffix(param, "*") {
+			if strings.HasPrefix(name, strings.TrimSuffix(param, "*")) {
+				return true
+			}
+		} else if name == param {
+			return true
+		}
+	}
+	return false
+}
+
+// key returns the canonical url, or the url itself when
+// it can't be parsed, for keying maps of pages by url
+func (c *Canonicalizer) key(urlStr string) string {
+
+	if c == nil {
+		c = defaultCanonicalizer
+	}
+	canonical, err := c.Canonical(urlStr)
+	if err != nil {
+		return urlStr
+	}
+	return canonical
+}
+
+// pageKey returns the canonical url of the page, taken
+// from its <link rel="canonical"> when it has one
+func (c *Canonicalizer) pageKey(el *html.Node, pageURL string) string {
+
+	if link := canonicalLink.MatchFirst(el); link != nil {
+		href, _ := attr(link, "href")
+		if resolved, err := resolveURL(href, pageURL); err == nil && href != "" {
+			return c.key(resolved)
+		}
+	}
+	return c.key(pageURL)
+}
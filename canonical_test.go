@@ -0,0 +1,173 @@
+package scraper
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type CanonicalTest struct {
+	URL       string
+	Canonical string
+}
+
+var canonicalTests = []CanonicalTest{
+	CanonicalTest{"http://a.com/x?b=1&a=2#frag", "http://a.com/x?a=2&b=1"},
+	CanonicalTest{"HTTP://A.com/x?a=2&b=1", "http://a.com/x?a=2&b=1"},
+	CanonicalTest{"http://a.com/x/../x", "http://a.com/x"},
+	CanonicalTest{"http://a.com/./y/./z/", "http://a.com/y/z/"},
+	CanonicalTest{"HTTP://Example.COM", "http://example.com/"},
+	CanonicalTest{"http://example.com:80/a", "http://example.com/a"},
+	CanonicalTest{"https://example.com:443/?", "https://example.com/"},
+	CanonicalTest{"https://example.com:8443/a", "https://example.com:8443/a"},
+	CanonicalTest{"http://a.com/?utm_source=x&UTM_Medium=y&id=1", "http://a.com/?id=1"},
+	CanonicalTest{"http://a.com/?gclid=1&fbclid=2", "http://a.com/"},
+	CanonicalTest{"http://a.com/%7Euser", "http://a.com/~user"},
+	CanonicalTest{"http://a.com/a%2Fb", "http://a.com/a%2Fb"},
+	CanonicalTest{"http://a.com/a%2fb", "http://a.com/a%2Fb"},
+	CanonicalTest{"http://a.com/a/b", "http://a.com/a/b"},
+	CanonicalTest{"http://a.com/%41%2e%62%20c", "http://a.com/A.b%20c"},
+	CanonicalTest{"http://a.com/x/%2E%2E/y", "http://a.com/y"},
+}
+
+func TestCanonicalURL(t *testing.T) {
+
+	for _, test := range canonicalTests {
+		canonical, err := CanonicalURL(test.URL)
+		if err != nil {
+			t.Errorf("%+v: %v", test, err)
+			continue
+		}
+		if canonical != test.Canonical {
+			t.Errorf("%+v: Received %q", test, canonical)
+		}
+	}
+}
+
+func TestCanonicalizer_StripParams(t *testing.T) {
+
+	c := &Canonicalizer{StripParams: []string{"session*", "ref"}}
+	canonical, err := c.Canonical("http://a.com/?sessionid=1&ref=2&utm_source=3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if canonical != "http://a.com/?utm_source=3" {
+		t.Fatalf("Unexpected canonical url %q", canonical)
+	}
+}
+
+func TestCrawl_CanonicalLink(t *testing.T) {
+
+	site := MemoryGetter{
+		"http://example.com/": `<title>home</title>
+			<a href="/article">article</a>
+			<a href="/article?utm_source=home">tracked</a>
+			<a href="/article?print=1">print</a>`,
+		"http://example.com/article": `<title>article</title>
+			<link rel="canonical" href="/article">`,
+		"http://example.com/article?print=1": `<title>print</title>
+			<link rel="canonical" href="http://example.com/article">`,
+	}
+
+	results, err := New("http://example.com/", nil, site).
+		Crawl("a[href]", CrawlOptions{
+			Rules: []CrawlRule{CrawlRule{"", Sel{"title": "title"}}},
+		}).
+		Done()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []map[string]string{
+		{"title": "home"},
+		{"title": "article"},
+	}
+	if !reflect.DeepEqual(results, expected) {
+		t.Fatalf("Expected %v, received %v", expected, results)
+	}
+}
+
+func TestCanonical_Fetches(t *testing.T) {
+
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/" {
+				io.WriteString(w, `<a href="/page?b=1&amp;a=2">1</a>`+
+					`<a href="/page?a=2&amp;b=1#top">2</a>`+
+					`<a href="/page?a=2&amp;b=1&amp;utm_source=x">3</a>`)
+				return
+			}
+			atomic.AddInt32(&requests, 1)
+			io.WriteString(w, "<p>page</p>")
+		}))
+	defer server.Close()
+
+	// The document cache keys pages by canonical url
+	results, err := NewWithOptions(context.Background(), server.URL, Options{
+		DocumentCache: 1 << 20,
+	}).Follow("a[href]").Select(Sel{"value": "p"}).Done()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 3 || atomic.LoadInt32(&requests) != 1 {
+		t.Fatalf("Expected 3 results from 1 request, received %v from %v",
+			results, requests)
+	}
+
+	// So does the CachingGetter
+	getter := &CachingGetter{Getter: HTTPGetter(), Dir: t.TempDir(), TTL: time.Hour}
+	verifyGetter(t, getter, server.URL+"/page?utm_campaign=y&b=1&a=2", "<p>page</p>")
+	verifyGetter(t, getter, server.URL+"/page?a=2&b=1", "<p>page</p>")
+	if n := atomic.LoadInt32(&requests); n != 2 {
+		t.Fatalf("Expected 2 requests, received %v", n)
+	}
+}
+
+func TestCanonical_URLKey(t *testing.T) {
+
+	site := MemoryGetter{
+		"http://example.com/": `<a href="/item">item</a>
+			<a href="/print">print</a>`,
+		"http://example.com/item": `<p class="name">item</p>`,
+		"http://example.com/print": `<link rel="canonical" href="/item">
+			<div><p class="name">print</p></div>`,
+	}
+
+	results, err := NewWithOptions(context.Background(), "http://example.com/", Options{
+		Getter: site,
+		URLKey: "url",
+	}).Follow("a[href]").Filter("div").Select(Sel{"name": ".name"}).Done()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []map[string]string{
+		{"name": "print", "url": "http://example.com/item"},
+	}
+	if !reflect.DeepEqual(results, expected) {
+		t.Fatalf("Expected %v, received %v", expected, results)
+	}
+
+	results, err = NewWithOptions(context.Background(), "http://example.com/", Options{
+		Getter: site,
+		URLKey: "url",
+	}).Crawl("a[href]", CrawlOptions{
+		Rules: []CrawlRule{CrawlRule{"/item", Sel{"name": ".name"}}},
+	}).Done()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = []map[string]string{
+		{"name": "item", "url": "http://example.com/item"},
+	}
+	if !reflect.DeepEqual(results, expected) {
+		t.Fatalf("Expected %v, received %v", expected, results)
+	}
+}
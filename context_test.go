@@ -0,0 +1,102 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const contextTestHTML = `
+	<a href="/page1">Page1</a>
+	<a href="/page2">Page2</a>
+	<a href="/page3">Page3</a>`
+
+func TestNewWithContext_Cancelled(t *testing.T) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	url := "localhost"
+	getter := MemoryGetter{url: contextTestHTML}
+	results, err := NewWithContext(ctx, url, nil, getter).Done()
+
+	if results != nil {
+		t.Fatalf("Results should be nil")
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected %q, received %v", context.Canceled, err)
+	}
+}
+
+func TestFollow_CancelledMidway(t *testing.T) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	getter := &cancelGetter{
+		MemoryGetter: MemoryGetter{
+			"http://localhost":       contextTestHTML,
+			"http://localhost/page1": "<p>1</p>",
+			"http://localhost/page2": "<p>2</p>",
+			"http://localhost/page3": "<p>3</p>",
+		},
+		cancel: cancel,
+		after:  2,
+	}
+
+	results, err := NewWithContext(ctx, "http://localhost", nil,
+		urlResolver{getter}).
+		Follow("a[href]").
+		Select(Sel{"value": "p"}).
+		Done()
+
+	if results != nil {
+		t.Fatalf("Results should be nil")
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected %q, received %v", context.Canceled, err)
+	}
+	if getter.calls != 2 {
+		t.Fatalf("Expected 2 requests, received %v", getter.calls)
+	}
+}
+
+func TestHTTPGetter_Deadline(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			<-r.Context().Done()
+		}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	getter := HTTPGetter().(ContextGetter)
+	_, err := getter.GetContext(ctx, server.URL, "")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected %q, received %v", context.DeadlineExceeded, err)
+	}
+}
+
+// cancelGetter cancels its context once
+// it has handled the given number of requests
+type cancelGetter struct {
+	MemoryGetter
+	cancel func()
+	after  int
+	calls  int
+}
+
+func (g *cancelGetter) GetContext(ctx context.Context, url string, srcURL string) (io.ReadCloser, error) {
+
+	g.calls++
+	if g.calls == g.after {
+		g.cancel()
+	}
+	return g.MemoryGetter.GetContext(ctx, url, srcURL)
+}
@@ -0,0 +1,354 @@
+package scraper
+
+import (
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	css "github.com/andybalholm/cascadia"
+	"golang.org/x/net/html"
+	"golang.org/x/net/publicsuffix"
+)
+
+// Scope decides which hosts and paths a Crawl
+// may follow links to, from the page it started on.
+// Hosts are compared by name without their ports, so
+// example.com:80 and example.com are the same host.
+type Scope int
+
+const (
+	// SameHost only follows links to the starting host
+	SameHost Scope = iota
+	// SameDomain follows links to any host of the starting host's
+	// registered domain, so from www.example.com to blog.example.com
+	SameDomain
+	// Subdomains follows links to the starting
+	// host and any host under it
+	Subdomains
+	// SamePath only follows links to the starting host
+	// under the directory of the starting page
+	SamePath
+	// AnyHost follows links anywhere
+	AnyHost
+)
+
+// CrawlOptions configures which pages a Crawl reaches
+type CrawlOptions struct {
+	// MaxDepth is how many links away from the starting
+	// page the crawl goes, zero for no limit
+	MaxDepth int
+	// MaxPages limits how many pages are crawled, counting
+	// the starting page but not pages that couldn't be got,
+	// zero for no limit
+	MaxPages int
+	Scope    Scope
+	// Include, when not empty, only follows links to
+	// urls matching one of its regular expressions
+	Include []string
+	// Exclude never follows links to urls
+	// matching one of its regular expressions
+	Exclude []string
+	// Rules select data from the pages whose urls match them
+	Rules []CrawlRule
+}
+
+// CrawlRule selects data from the pages a Crawl reaches that
+// have urls matching its Pattern. Each field's nth match goes
+// in the page's nth record, so a field per element of a list
+// gives one record for each element.
+type CrawlRule struct {
+	// Pattern is a regular expression matched against
+	// each page's url, with an empty one matching all
+	Pattern string
+	Select  Sel
+}
+
+// crawler holds the compiled CrawlOptions
+type crawler struct {
+	CrawlOptions
+	sel     string
+	cssSel  css.Selector
+	include []*regexp.Regexp
+	exclude []*regexp.Regexp
+	rules   []crawlRule
+}
+
+type crawlRule struct {
+	pattern *regexp.Regexp
+	names   []string
+	sels    map[string]string
+	cssSels map[string]css.Selector
+}
+
+func (s *scraper) Crawl(linkSelector string, opts CrawlOptions) Scraper {
+
+	if s.Error != nil {
+		return s
+	}
+
+	c, err := newCrawler(linkSelector, opts)
+	if err != nil {
+		return s.setError(err)
+	}
+
+	return s.addStep(step{true, true, func(n node) ([]node, error) {
+		return n.Crawl(c)
+	}, nil})
+}
+
+func newCrawler(linkSelector string, opts CrawlOptions) (*crawler, error) {
+
+	cssSel, err := css.Compile(linkSelector)
+	if err != nil {
+		return nil, err
+	}
+	c := &crawler{CrawlOptions: opts, sel: linkSelector, cssSel: cssSel}
+
+	if c.include, err = compileAll(opts.Include); err != nil {
+		return nil, err
+	}
+	if c.exclude, err = compileAll(opts.Exclude); err != nil {
+		return nil, err
+	}
+
+	for _, rule := range opts.Rules {
+		pattern, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, err
+		}
+		compiled := crawlRule{pattern, nil, rule.Select, map[string]css.Selector{}}
+		for name, selector := range rule.Select {
+			if compiled.cssSels[name], err = css.Compile(selector); err != nil {
+				return nil, err
+			}
+			compiled.names = append(compiled.names, name)
+		}
+		sort.Strings(compiled.names)
+		c.rules = append(c.rules, compiled)
+	}
+
+	return c, nil
+}
+
+// Crawl leads to this page and every page reached by following
+// the links matched on it, and on each page after it, breadth
+// first so pages are in order of how many links away they are
+func (r *result) Crawl(c *crawler) ([]node, error) {
+
+	start := &result{r.nFactory, r.URL, r.Element, r.Data, nil}
+	if err := c.selectRules(start); err != nil {
+		return nil, err
+	}
+
+	pages := []*result{start}
+	level := pages
+	visited := map[string]bool{
+		r.canonicalizer.key(r.URL):                true,
+		r.canonicalizer.pageKey(r.Element, r.URL): true,
+	}
+
+	for depth := 1; len(level) > 0 && !c.full(len(pages)) &&
+		(c.MaxDepth <= 0 || depth <= c.MaxDepth); depth++ {
+
+		var urls []string
+		for _, page := range level {
+			urls = append(urls, c.links(r, page, visited)...)
+		}
+		level = nil
+
+		// Fetch only as many pages as are left of MaxPages
+		// at once, so pages that fail don't use any up
+		for len(urls) > 0 && !c.full(len(pages)+len(level)) {
+
+			batch := urls
+			if left := c.MaxPages - len(pages) - len(level); c.MaxPages > 0 && len(batch) > left {
+				batch = batch[:left]
+			}
+			urls = urls[len(batch):]
+
+			els, errs := r.followURLs(batch)
+			for i, el := range els {
+
+				if err := errs[i]; err != nil {
+					if ctxErr := r.Context.Err(); ctxErr != nil {
+						return nil, ctxErr
+					}
+					if isFatal(err) {
+						return nil, err
+					}
+					continue
+				}
+
+				// Pages naming an already crawled page as their
+				// canonical url are duplicates of it
+				key := r.canonicalizer.pageKey(el, batch[i])
+				if key != r.canonicalizer.key(batch[i]) && visited[key] {
+					continue
+				}
+				visited[key] = true
+
+				page := &result{r.nFactory, batch[i], el, r.Data, nil}
+				if err := c.selectRules(page); err != nil {
+					return nil, err
+				}
+				level = append(level, page)
+			}
+		}
+		pages = append(pages, level...)
+	}
+
+	nodes := make([]node, len(pages))
+	for i, page := range pages {
+		r.Nodes = append(r.Nodes, page)
+		nodes[i] = page
+	}
+	return nodes, nil
+}
+
+// full reports whether the pages crawled have reached MaxPages
+func (c *crawler) full(crawled int) bool {
+	return c.MaxPages > 0 && crawled >= c.MaxPages
+}
+
+// links returns the absolute urls of the page's links the
+// crawl from start should follow, marking them visited
+func (c *crawler) links(start *result, page *result, visited map[string]bool) []string {
+
+	var urls []string
+	for _, link := range c.cssSel.MatchAll(page.Element) {
+
+		href, err := textOrAttr(c.sel, link)
+		if err != nil {
+			continue
+		}
+		url, err := resolveURL(href, page.URL)
+		if err != nil {
+			continue
+		}
+
+		key := start.canonicalizer.key(url)
+		if visited[key] || !c.allowed(start.URL, url) {
+			continue
+		}
+		visited[key] = true
+		urls = append(urls, url)
+	}
+	return urls
+}
+
+// allowed reports whether the url is within the crawl's
+// scope, and not filtered out by its regular expressions
+func (c *crawler) allowed(startURL string, urlStr string) bool {
+
+	uri, err := url.Parse(urlStr)
+	if err != nil || (uri.Scheme != "http" && uri.Scheme != "https") {
+		return false
+	}
+	if !c.inScope(startURL, uri) {
+		return false
+	}
+
+	for _, exclude := range c.exclude {
+		if exclude.MatchString(urlStr) {
+			return false
+		}
+	}
+	if len(c.include) == 0 {
+		return true
+	}
+	for _, include := range c.include {
+		if include.MatchString(urlStr) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *crawler) inScope(startURL string, uri *url.URL) bool {
+
+	start, err := url.Parse(startURL)
+	if err != nil {
+		return false
+	}
+
+	host, startHost := strings.ToLower(uri.Hostname()), strings.ToLower(start.Hostname())
+
+	switch c.Scope {
+	case AnyHost:
+		return true
+	case SameDomain:
+		domain, err := publicsuffix.EffectiveTLDPlusOne(host)
+		if err != nil {
+			return host == startHost
+		}
+		startDomain, err := publicsuffix.EffectiveTLDPlusOne(startHost)
+		return err == nil && domain == startDomain
+	case Subdomains:
+		return host == startHost || strings.HasSuffix(host, "."+startHost)
+	case SamePath:
+		dir := start.Path[:strings.LastIndex(start.Path, "/")+1]
+		return host == startHost && strings.HasPrefix(uri.Path, dir)
+	}
+	return host == startHost
+}
+
+// selectRules sets the page's data to the records
+// selected by every rule matching the page
+func (c *crawler) selectRules(page *result) error {
+
+	var data []map[string]string
+	matched := false
+	for _, rule := range c.rules {
+		if !rule.pattern.MatchString(page.URL) {
+			continue
+		}
+		records, err := rule.selectFields(page.Element)
+		if err != nil {
+			return err
+		}
+		data = append(data, records...)
+		matched = true
+	}
+
+	if matched {
+		page.addURL(data)
+		page.Data = data
+	}
+	return nil
+}
+
+// selectFields selects all the rule's fields together,
+// with the nth match of each field in the nth record
+func (rule *crawlRule) selectFields(el *html.Node) ([]map[string]string, error) {
+
+	var records []map[string]string
+	for _, name := range rule.names {
+		for i, n := range rule.cssSels[name].MatchAll(el) {
+
+			txt, err := textOrAttr(rule.sels[name], n)
+			if err != nil {
+				return nil, err
+			}
+
+			if i == len(records) {
+				records = append(records, make(map[string]string))
+			}
+			records[i][name] = txt
+		}
+	}
+	return records, nil
+}
+
+func compileAll(exprs []string) ([]*regexp.Regexp, error) {
+
+	var regexps []*regexp.Regexp
+	for _, expr := range exprs {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, err
+		}
+		regexps = append(regexps, re)
+	}
+	return regexps, nil
+}
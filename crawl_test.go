@@ -0,0 +1,188 @@
+package scraper
+
+import (
+	"reflect"
+	"testing"
+)
+
+var crawlTestSite = MemoryGetter{
+	"http://example.com/": `<title>home</title>
+		<a href="/a">a</a>
+		<a href="/a#top">a again</a>
+		<a href="/docs/one">one</a>
+		<a href="http://blog.example.com/">blog</a>
+		<a href="http://other.com/">other</a>
+		<a href="mailto:someone@example.com">mail</a>`,
+	"http://example.com/a": `<title>a</title>
+		<a href="/b?y=2&amp;x=1">b</a>
+		<a href="HTTP://Example.com:80/">home</a>`,
+	"http://example.com/b?y=2&x=1": `<title>b</title>
+		<a href="/b?x=1&amp;y=2">b again</a>`,
+	"http://example.com/docs/one": `<title>one</title><h1>One</h1>
+		<a href="two">two</a>`,
+	"http://example.com/docs/two": `<title>two</title><h1>Two</h1>`,
+	"http://blog.example.com/": `<title>blog</title>
+		<a href="http://example.com/a">a</a>`,
+	"http://other.com/": `<title>other</title>`,
+}
+
+type CrawlTest struct {
+	URL    string
+	Opts   CrawlOptions
+	Titles []string
+}
+
+var crawlTests = []CrawlTest{
+	CrawlTest{"http://example.com/", CrawlOptions{},
+		[]string{"home", "a", "one", "b", "two"}},
+	CrawlTest{"http://example.com/", CrawlOptions{MaxDepth: 1},
+		[]string{"home", "a", "one"}},
+	CrawlTest{"http://example.com/", CrawlOptions{MaxPages: 2},
+		[]string{"home", "a"}},
+	CrawlTest{"http://example.com/", CrawlOptions{Scope: SameDomain},
+		[]string{"home", "a", "one", "blog", "b", "two"}},
+	CrawlTest{"http://blog.example.com/", CrawlOptions{Scope: Subdomains},
+		[]string{"blog"}},
+	CrawlTest{"http://example.com/", CrawlOptions{Scope: AnyHost},
+		[]string{"home", "a", "one", "blog", "other", "b", "two"}},
+	CrawlTest{"http://example.com/docs/one", CrawlOptions{Scope: SamePath},
+		[]string{"one", "two"}},
+	CrawlTest{"http://example.com/", CrawlOptions{Include: []string{"/docs/"}},
+		[]string{"home", "one", "two"}},
+	CrawlTest{"http://example.com/", CrawlOptions{Exclude: []string{"/docs/", `\?`}},
+		[]string{"home", "a"}},
+}
+
+func TestCrawl(t *testing.T) {
+
+	for _, test := range crawlTests {
+
+		test.Opts.Rules = []CrawlRule{CrawlRule{"", Sel{"title": "title"}}}
+		results, err := New(test.URL, nil, crawlTestSite).
+			Crawl("a[href]", test.Opts).
+			Done()
+		if err != nil {
+			t.Errorf("%+v: %v", test, err)
+			continue
+		}
+
+		var titles []string
+		for _, result := range results {
+			titles = append(titles, result["title"])
+		}
+		if !reflect.DeepEqual(titles, test.Titles) {
+			t.Errorf("%+v: Expected %v, received %v", test, test.Titles, titles)
+		}
+	}
+}
+
+func TestCrawl_Rules(t *testing.T) {
+
+	results, err := New("http://example.com/", nil, crawlTestSite).
+		Crawl("a[href]", CrawlOptions{
+			Rules: []CrawlRule{
+				CrawlRule{`/docs/`, Sel{"heading": "h1"}},
+			},
+		}).
+		Done()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []map[string]string{
+		{"heading": "One"},
+		{"heading": "Two"},
+	}
+	if !reflect.DeepEqual(results, expected) {
+		t.Fatalf("Expected %v, received %v", expected, results)
+	}
+}
+
+func TestCrawl_RuleFields(t *testing.T) {
+
+	results, err := New("http://example.com/docs/two", nil, crawlTestSite).
+		Crawl("a[href]", CrawlOptions{
+			Rules: []CrawlRule{
+				CrawlRule{"", Sel{"title": "title", "heading": "h1"}},
+			},
+		}).
+		Done()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []map[string]string{
+		{"title": "two", "heading": "Two"},
+	}
+	if !reflect.DeepEqual(results, expected) {
+		t.Fatalf("Expected %v, received %v", expected, results)
+	}
+}
+
+func TestCrawl_InvalidPattern(t *testing.T) {
+
+	_, err := New("http://example.com/", nil, crawlTestSite).
+		Crawl("a[href]", CrawlOptions{Exclude: []string{"("}}).
+		Done()
+	if err == nil {
+		t.Fatalf("Expected an error for an invalid pattern")
+	}
+}
+
+func TestCrawl_MaxPagesFailures(t *testing.T) {
+
+	site := MemoryGetter{
+		"http://example.com/": `<title>home</title>
+			<a href="/gone">gone</a>
+			<a href="/also-gone">also gone</a>
+			<a href="/a">a</a>`,
+		"http://example.com/a": `<title>a</title><a href="/b">b</a>`,
+		"http://example.com/b": `<title>b</title>`,
+	}
+
+	results, err := New("http://example.com/", nil, site).
+		Crawl("a[href]", CrawlOptions{
+			MaxPages: 3,
+			Rules:    []CrawlRule{CrawlRule{"", Sel{"title": "title"}}},
+		}).
+		Done()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Pages that couldn't be got don't count
+	expected := []map[string]string{{"title": "home"}, {"title": "a"}, {"title": "b"}}
+	if !reflect.DeepEqual(results, expected) {
+		t.Fatalf("Expected %v, received %v", expected, results)
+	}
+}
+
+func TestCrawl_ScopePorts(t *testing.T) {
+
+	site := MemoryGetter{
+		"http://example.com/docs/": `<title>docs</title>
+			<a href="http://example.com:80/docs/a">a</a>
+			<a href="http://example.com:8080/docs/b">b</a>`,
+		"http://example.com:80/docs/a":   `<title>a</title>`,
+		"http://example.com:8080/docs/b": `<title>b</title>`,
+	}
+
+	for _, scope := range []Scope{SameHost, SamePath} {
+
+		results, err := New("http://example.com/docs/", nil, site).
+			Crawl("a[href]", CrawlOptions{
+				Scope: scope,
+				Rules: []CrawlRule{CrawlRule{"", Sel{"title": "title"}}},
+			}).
+			Done()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Hosts are compared without their ports
+		expected := []map[string]string{{"title": "docs"}, {"title": "a"}, {"title": "b"}}
+		if !reflect.DeepEqual(results, expected) {
+			t.Errorf("%v: Expected %v, received %v", scope, expected, results)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package scraper
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+)
+
+// parse parses a page's body, first decompressing it and
+// converting it to UTF-8 using its Content-Encoding and
+// Content-Type headers when the Getter knows them, and
+// failing with a LimitError if the page is over the Limits
+func (n *nFactory) parse(url string, r io.Reader) (*html.Node, error) {
+
+	header := responseHeader(r)
+	compressed := &countingReader{Reader: r}
+
+	// Undo any compression the server applied without being
+	// asked, then transcode the text to UTF-8, with the charset
+	// taken from a BOM, the Content-Type or a <meta charset>
+	body, decompressed, err := decompress(compressed, header.Get("Content-Encoding"))
+	if err != nil {
+		return nil, err
+	}
+
+	limits := n.Limits
+	if decompressed && limits.MaxRatio > 0 {
+		body = &ratioReader{
+			body, compressed, 0, &LimitError{url, "MaxRatio", 0, limits.MaxRatio},
+		}
+	}
+	if limits.MaxBodyBytes > 0 {
+		body = &bodyLimitReader{
+			body, 0, &LimitError{url, "MaxBodyBytes", limits.MaxBodyBytes, 0},
+		}
+	}
+
+	body, err = charset.NewReader(body, header.Get("Content-Type"))
+	if err != nil {
+		return nil, err
+	}
+
+	el, err := html.Parse(body)
+	if err != nil {
+		return nil, err
+	}
+
+	if limits.MaxNodes > 0 && countNodes(el, limits.MaxNodes) > limits.MaxNodes {
+		return nil, &LimitError{url, "MaxNodes", int64(limits.MaxNodes), 0}
+	}
+	return el, nil
+}
+
+// decompress unwraps the encoding named in the header or,
+// when there is none (such as for files), gzip detected
+// from its magic bytes at the start of r. A zlib header is
+// too short to tell apart from text, so is not sniffed.
+// It reports whether the data needed decompressing.
+func decompress(r io.Reader, encoding string) (io.Reader, bool, error) {
+
+	br := bufio.NewReader(r)
+
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "gzip", "x-gzip":
+		return gzipReader(br)
+	case "deflate":
+		// Servers send either zlib wrapped or raw deflate data
+		if isZlib(br) {
+			return zlibReader(br)
+		}
+		return flate.NewReader(br), true, nil
+	case "br":
+		return brotli.NewReader(br), true, nil
+	case "", "identity":
+	default:
+		return br, false, nil
+	}
+
+	magic, _ := br.Peek(2)
+	if len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		return gzipReader(br)
+	}
+	return br, false, nil
+}
+
+func gzipReader(r io.Reader) (io.Reader, bool, error) {
+	zr, err := gzip.NewReader(r)
+	return zr, true, err
+}
+
+func zlibReader(r io.Reader) (io.Reader, bool, error) {
+	zr, err := zlib.NewReader(r)
+	return zr, true, err
+}
+
+// isZlib reports whether r starts with a zlib header
+func isZlib(r *bufio.Reader) bool {
+
+	header, _ := r.Peek(2)
+	if len(header) < 2 {
+		return false
+	}
+	return header[0]&0x0f == 8 &&
+		header[0]>>4 <= 7 &&
+		(uint16(header[0])<<8|uint16(header[1]))%31 == 0
+}
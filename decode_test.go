@@ -0,0 +1,78 @@
+package scraper
+
+import (
+	"bytes"
+	"compress/zlib"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+)
+
+type DecodeTest struct {
+	Getter Getter
+	URL    string
+	Exp    string
+}
+
+func TestDecode(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/latin1":
+				w.Header().Set("Content-Type", "text/html; charset=ISO-8859-1")
+				w.Write([]byte("<p>caf\xe9 cr\xe8me</p>"))
+			case "/brotli":
+				var buf bytes.Buffer
+				bw := brotli.NewWriter(&buf)
+				bw.Write([]byte("<p>brotli café</p>"))
+				bw.Close()
+				w.Header().Set("Content-Encoding", "br")
+				w.Write(buf.Bytes())
+			case "/deflate":
+				var buf bytes.Buffer
+				zw := zlib.NewWriter(&buf)
+				zw.Write([]byte("<p>deflate café</p>"))
+				zw.Close()
+				w.Header().Set("Content-Encoding", "deflate")
+				w.Write(buf.Bytes())
+			}
+		}))
+	defer server.Close()
+
+	tests := []DecodeTest{
+		DecodeTest{HTTPGetter(), server.URL + "/latin1", "café crème"},
+		DecodeTest{HTTPGetter(), server.URL + "/brotli", "brotli café"},
+		DecodeTest{HTTPGetter(), server.URL + "/deflate", "deflate café"},
+		DecodeTest{
+			MemoryGetter{"url": "<meta charset=\"windows-1252\"><p>quote: \x93hi\x94</p>"},
+			"url", "quote: “hi”"},
+		DecodeTest{
+			MemoryGetter{"url": "\xef\xbb\xbf<p>bom café</p>"},
+			"url", "bom café"},
+		// Text that happens to start like a zlib header
+		DecodeTest{MemoryGetter{"url": "hb <p>hello</p>"}, "url", "hello"},
+		DecodeTest{MemoryGetter{"url": "x <p>hello</p>"}, "url", "hello"},
+		DecodeTest{
+			FileGetter{"url": "./testFiles/shift_jis.html"},
+			"url", "こんにちは"},
+		DecodeTest{
+			FileGetter{"url": "./testFiles/page.html.gz"},
+			"url", "compressed café"},
+	}
+
+	for _, test := range tests {
+
+		results, err := New(test.URL, nil, test.Getter).
+			Select(Sel{"value": "p"}).
+			Done()
+		if err != nil {
+			t.Fatalf("%s: %v", test.URL, err)
+		}
+		if len(results) != 1 || results[0]["value"] != test.Exp {
+			t.Errorf("%s: Expected %q, received %v", test.URL, test.Exp, results)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package scraper
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"unsafe"
+
+	"golang.org/x/net/html"
+)
+
+// documentCache makes sure a scrape fetches and parses each url
+// it follows only once at a time, with concurrent Follows of a
+// url waiting for the first. When given a size it also keeps
+// the most recently used pages for later Follows to reuse,
+// including Follows of the url a page names as canonical.
+type documentCache struct {
+	max           int64
+	canonicalizer *Canonicalizer
+	mu            sync.Mutex
+	loading       map[string]*documentLoad
+	docs          map[string]*list.Element
+	lru           *list.List
+	size          int64
+}
+
+type documentLoad struct {
+	done chan struct{}
+	el   *html.Node
+	err  error
+}
+
+type document struct {
+	urls []string
+	el   *html.Node
+	size int64
+}
+
+func newDocumentCache(max int64, canonicalizer *Canonicalizer) *documentCache {
+	return &documentCache{
+		max:           max,
+		canonicalizer: canonicalizer,
+		loading:       make(map[string]*documentLoad),
+		docs:          make(map[string]*list.Element),
+		lru:           list.New(),
+	}
+}
+
+// load returns the parsed page for the url, calling
+// fetch only if it is neither kept nor being loaded
+func (c *documentCache) load(ctx context.Context, url string,
+	fetch func() (*html.Node, error)) (*html.Node, error) {
+
+	c.mu.Lock()
+	if item, ok := c.docs[url]; ok {
+		c.lru.MoveToFront(item)
+		c.mu.Unlock()
+		return item.Value.(*document).el, nil
+	}
+
+	if load, ok := c.loading[url]; ok {
+		c.mu.Unlock()
+		select {
+		case <-load.done:
+			return load.el, load.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	load := &documentLoad{done: make(chan struct{})}
+	c.loading[url] = load
+	c.mu.Unlock()
+
+	load.el, load.err = fetch()
+
+	c.mu.Lock()
+	delete(c.loading, url)
+	if load.err == nil {
+		c.keep(url, load.el)
+	}
+	c.mu.Unlock()
+
+	close(load.done)
+	return load.el, load.err
+}
+
+// keep adds the page to the cache under its url and any
+// <link rel="canonical"> url, dropping the least recently
+// used pages until it fits within the max size
+func (c *documentCache) keep(url string, el *html.Node) {
+
+	if c.max <= 0 {
+		return
+	}
+	size := documentSize(el)
+	if size > c.max {
+		return
+	}
+
+	urls := []string{url}
+	if canonical := c.canonicalizer.pageKey(el, url); canonical != url {
+		urls = append(urls, canonical)
+	}
+	item := c.lru.PushFront(&document{urls, el, size})
+	for _, url := range urls {
+		c.docs[url] = item
+	}
+	c.size += size
+
+	for c.size > c.max {
+		back := c.lru.Back()
+		oldest := c.lru.Remove(back).(*document)
+		for _, url := range oldest.urls {
+			// A later page may have taken over its canonical url
+			if c.docs[url] == back {
+				delete(c.docs, url)
+			}
+		}
+		c.size -= oldest.size
+	}
+}
+
+// documentSize estimates the memory used by the parsed page
+func documentSize(n *html.Node) int64 {
+
+	size := int64(unsafe.Sizeof(*n)) + int64(len(n.Data)+len(n.Namespace))
+	for _, a := range n.Attr {
+		size += int64(unsafe.Sizeof(a)) + int64(len(a.Namespace)+len(a.Key)+len(a.Val))
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		size += documentSize(c)
+	}
+	return size
+}
@@ -0,0 +1,140 @@
+package scraper
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestDocumentCache_Follow(t *testing.T) {
+
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/":
+				io.WriteString(w, strings.Repeat(`<div><a href="/shared">next</a></div>`, 3))
+			case "/shared":
+				atomic.AddInt32(&requests, 1)
+				io.WriteString(w, "<p>shared</p>")
+			}
+		}))
+	defer server.Close()
+
+	for _, size := range []int64{0, 1 << 20} {
+
+		atomic.StoreInt32(&requests, 0)
+		results, err := NewWithOptions(context.Background(), server.URL, Options{
+			DocumentCache: size,
+		}).
+			Filter("div").
+			Follow("a[href]").
+			Select(Sel{"value": "p"}).
+			Done()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(results) != 3 {
+			t.Fatalf("Expected 3 results, received %v", results)
+		}
+
+		expected := int32(3)
+		if size > 0 {
+			expected = 1
+		}
+		if n := atomic.LoadInt32(&requests); n != expected {
+			t.Errorf("DocumentCache %v: Expected %v requests, received %v", size, expected, n)
+		}
+	}
+}
+
+func TestDocumentCache_InFlight(t *testing.T) {
+
+	cache := newDocumentCache(1<<20, nil)
+	started, release := make(chan struct{}), make(chan struct{})
+	var fetches int32
+
+	fetch := func() (*html.Node, error) {
+		if atomic.AddInt32(&fetches, 1) == 1 {
+			close(started)
+		}
+		<-release
+		return html.Parse(strings.NewReader("<p>page</p>"))
+	}
+
+	var wg sync.WaitGroup
+	results := make([]*html.Node, 5)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], _ = cache.load(context.Background(), "http://example.com", fetch)
+		}(i)
+	}
+
+	<-started
+	close(release)
+	wg.Wait()
+
+	if n := atomic.LoadInt32(&fetches); n != 1 {
+		t.Fatalf("Expected 1 fetch, received %v", n)
+	}
+	for _, el := range results {
+		if el == nil || el != results[0] {
+			t.Fatalf("Expected every load to share the page")
+		}
+	}
+}
+
+func TestDocumentCache_Evict(t *testing.T) {
+
+	page, _ := html.Parse(strings.NewReader("<p>page</p>"))
+	size := documentSize(page)
+	cache := newDocumentCache(2*size, nil)
+
+	fetches := 0
+	fetch := func() (*html.Node, error) {
+		fetches++
+		return page, nil
+	}
+
+	for _, url := range []string{"/a", "/b", "/a", "/c", "/a", "/b"} {
+		cache.load(context.Background(), url, fetch)
+	}
+
+	// /b was the least recently used page when /c was kept
+	if fetches != 4 {
+		t.Fatalf("Expected 4 fetches, received %v", fetches)
+	}
+	if cache.size > cache.max {
+		t.Fatalf("Cache grew to %v over its max of %v", cache.size, cache.max)
+	}
+}
+
+func TestDocumentCache_Canonical(t *testing.T) {
+
+	page, _ := html.Parse(strings.NewReader(
+		`<link rel="canonical" href="/a"><p>page</p>`))
+	cache := newDocumentCache(1<<20, nil)
+
+	fetches := 0
+	fetch := func() (*html.Node, error) {
+		fetches++
+		return page, nil
+	}
+
+	// The page is reused for the url it names as canonical
+	for _, url := range []string{"http://example.com/a?ref=1", "http://example.com/a"} {
+		cache.load(context.Background(), url, fetch)
+	}
+	if fetches != 1 {
+		t.Fatalf("Expected 1 fetch, received %v", fetches)
+	}
+}
@@ -0,0 +1,139 @@
+package scraper
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+)
+
+// DirGetter is a Getter that retrieves urls from files under
+// the directory it names, found by FixturePath, so whole
+// sites can be checked in as fixtures and scraped offline.
+type DirGetter string
+
+// FSGetter is a DirGetter for any fs.FS, such as an embed.FS
+// (use fs.Sub when the fixtures are in a subdirectory)
+type FSGetter struct {
+	fs.FS
+}
+
+// FixtureError is returned by a DirGetter, FSGetter or
+// ArchiveGetter when there is no file for the requested url
+type FixtureError struct {
+	URL  string
+	Path string
+}
+
+// FixturePath returns the path, relative to a DirGetter's
+// directory, of the file serving the url. It is the url's
+// host, lowercased and with any port after an underscore,
+// followed by its path, adding index.html to paths ending
+// in a slash. A query is hashed onto the end of the name,
+// so "http://example.com:8080/list?page=2" is served by
+// "example.com_8080/list__q" and 12 hex characters.
+func FixturePath(urlStr string) (string, error) {
+
+	uri, err := url.Parse(urlStr)
+	if err != nil {
+		return "", err
+	}
+	if uri.Host == "" {
+		return "", fmt.Errorf("No host found in url %q", urlStr)
+	}
+
+	host := strings.Replace(strings.ToLower(uri.Host), ":", "_", 1)
+
+	name := uri.Path
+	if name == "" || strings.HasSuffix(name, "/") {
+		name += "index.html"
+	}
+	name = path.Join(host, path.Clean("/"+name))
+
+	if uri.RawQuery != "" {
+		// Encode sorts the values so their order doesn't matter
+		sum := sha256.Sum256([]byte(uri.Query().Encode()))
+		name += "__q" + hex.EncodeToString(sum[:])[:12]
+	}
+
+	return name, nil
+}
+
+// Get retrieves the fixture file for the url
+func (d DirGetter) Get(url string, srcURL string) (io.ReadCloser, error) {
+	return d.GetContext(context.Background(), url, srcURL)
+}
+
+// GetContext retrieves the fixture file for the
+// url, failing if the context is already done
+func (d DirGetter) GetContext(ctx context.Context, url string, srcURL string) (io.ReadCloser, error) {
+	return FSGetter{os.DirFS(string(d))}.GetContext(ctx, url, srcURL)
+}
+
+// Get retrieves the fixture file for the url
+func (g FSGetter) Get(url string, srcURL string) (io.ReadCloser, error) {
+	return g.GetContext(context.Background(), url, srcURL)
+}
+
+// GetContext retrieves the fixture file for the
+// url, failing if the context is already done
+func (g FSGetter) GetContext(ctx context.Context, url string, srcURL string) (io.ReadCloser, error) {
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	resolved, err := resolveURL(url, srcURL)
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := FixturePath(resolved)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := g.open(name)
+	if errors.Is(err, fs.ErrNotExist) || errors.Is(err, fs.ErrInvalid) {
+		return nil, &FixtureError{resolved, name}
+	}
+	return file, err
+}
+
+// open opens the named file, or the index.html
+// inside it when the name is a directory
+func (g FSGetter) open(name string) (fs.File, error) {
+
+	file, err := g.FS.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if !info.IsDir() {
+		return file, nil
+	}
+
+	file.Close()
+	return g.FS.Open(path.Join(name, "index.html"))
+}
+
+func (e *FixtureError) Error() string {
+	return fmt.Sprintf("No fixture found for url %q, expected the file %q", e.URL, e.Path)
+}
+
+// Is matches fs.ErrNotExist, as the url has no fixture
+func (e *FixtureError) Is(target error) bool {
+	return target == fs.ErrNotExist
+}
@@ -0,0 +1,90 @@
+package scraper
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+type FixturePathTest struct {
+	URL  string
+	Path string
+}
+
+var fixturePathTests = []FixturePathTest{
+	FixturePathTest{"http://example.com", "example.com/index.html"},
+	FixturePathTest{"https://Example.com/", "example.com/index.html"},
+	FixturePathTest{"http://example.com/docs/", "example.com/docs/index.html"},
+	FixturePathTest{"http://example.com/a/../b.html", "example.com/b.html"},
+	FixturePathTest{"http://example.com/../../etc/passwd", "example.com/etc/passwd"},
+	FixturePathTest{"http://example.com:8080/page", "example.com_8080/page"},
+	FixturePathTest{"http://example.com/search?q=go&page=2", "example.com/search__qc1553b501a91"},
+	FixturePathTest{"http://example.com/search?page=2&q=go", "example.com/search__qc1553b501a91"},
+}
+
+func TestFixturePath(t *testing.T) {
+
+	for _, test := range fixturePathTests {
+		path, err := FixturePath(test.URL)
+		if err != nil {
+			t.Errorf("%+v: %v", test, err)
+			continue
+		}
+		if path != test.Path {
+			t.Errorf("%+v: Received %q", test, path)
+		}
+	}
+}
+
+func TestDirGetter(t *testing.T) {
+
+	getter := DirGetter("testFiles/site")
+
+	results, err := New("http://example.com/", nil, getter).
+		Follow("a[href]").
+		Select(Sel{"title": ".title"}).
+		Done()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []map[string]string{
+		{"title": "Docs"},
+		{"title": "Results for go, page 2"},
+	}
+	if !reflect.DeepEqual(results, expected) {
+		t.Fatalf("Expected %v, received %v", expected, results)
+	}
+
+	_, err = getter.Get("/missing", "http://example.com/")
+	var fixtureErr *FixtureError
+	if !errors.As(err, &fixtureErr) || fixtureErr.Path != "example.com/missing" {
+		t.Fatalf("Expected a FixtureError, received %v", err)
+	}
+}
+
+func TestFSGetter(t *testing.T) {
+
+	getter := FSGetter{fstest.MapFS{
+		"example.com/index.html": {Data: []byte(`<a href="about">About</a>`)},
+		"example.com/about":      {Data: []byte(`<p>About us</p>`)},
+	}}
+
+	results, err := New("http://example.com", nil, getter).
+		Follow("a[href]").
+		Select(Sel{"value": "p"}).
+		Done()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0]["value"] != "About us" {
+		t.Fatalf("Unexpected results %v", results)
+	}
+
+	_, err = New("http://other.com", nil, getter).Done()
+	var fixtureErr *FixtureError
+	if !errors.As(err, &fixtureErr) {
+		t.Fatalf("Expected a FixtureError, received %v", err)
+	}
+}
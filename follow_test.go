@@ -28,10 +28,10 @@ type FollowTest struct {
 }
 
 var followTestData = []FollowTest{
-	//FollowTest{Fol: ".d1 a[href]",	Sel: ".p1",		Exp: "P1Data1P1Data2P1Data3"},
-	// FollowTest{Fol: ".d1 a[href]",	Sel: ".p1 .b2",	Exp: "P1Data2"},
-	//FollowTest{Fol: ".d2 a[href]",	Sel: ".p2 .a3",	Exp: "P2Data3"},
-	//FollowTest{Fol: ".d3 a[href]",	Sel: ".p2",		Exp: "P2Data1P2Data2P2Data3"},
+	FollowTest{Fol: ".d1 a[href]",	Sel: ".p1",		Exp: "P1Data1P1Data2P1Data3"},
+	FollowTest{Fol: ".d1 a[href]",	Sel: ".p1 .b2",	Exp: "P1Data2"},
+	FollowTest{Fol: ".d2 a[href]",	Sel: ".p2 .a3",	Exp: "P2Data3"},
+	FollowTest{Fol: ".d3 a[href]",	Sel: ".p2",		Exp: "P2Data1P2Data2P2Data3"},
 }
 
 func TestFollow(t *testing.T) {
@@ -47,6 +47,8 @@ func followTest(t *testing.T, followSel string,
 		"http://localhost":			mainHTML,
 		"http://localhost/page1":	page1HTML,
 		"http://localhost/page2":	page2HTML,
+		// "//page2" is protocol relative, naming the host page2
+		"http://page2":	page2HTML,
 	}}
 	logger := log.New(os.Stdout, "", log.Lshortfile)
 	scraper := New("http://localhost", logger, getter)
@@ -0,0 +1,165 @@
+package scraper
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	css "github.com/andybalholm/cascadia"
+	"golang.org/x/net/html"
+)
+
+// Submit fills in and sends every form matched by
+// the selector, continuing with the response pages
+func (r *result) Submit(sel string, cssSel css.Selector, fields map[string]string) ([]node, error) {
+
+	forms := cssSel.MatchAll(r.Element)
+	if len(forms) == 0 {
+		return nil, fmt.Errorf("No form found matching %q", sel)
+	}
+
+	nodes := make([]node, 0, len(forms))
+	for _, form := range forms {
+
+		if form.Data != "form" {
+			return nil, fmt.Errorf(
+				"Selector %q matched a <%s> instead of a <form>", sel, form.Data)
+		}
+
+		values := formValues(form)
+		for name, value := range fields {
+			values.Set(name, value)
+		}
+
+		el, url, err := r.submitForm(form, values)
+		if err != nil {
+			return nil, err
+		}
+
+		node := &result{
+			r.nFactory, url, el, r.Data, nil,
+		}
+
+		r.Nodes = append(r.Nodes, node)
+		nodes = append(nodes, node)
+	}
+
+	return nodes, nil
+}
+
+// submitForm sends the form's values to its action the way
+// a browser would, returning the parsed response page
+func (r *result) submitForm(form *html.Node, values url.Values) (*html.Node, string, error) {
+
+	action, _ := attr(form, "action")
+	if action == "" {
+		action = r.URL
+	}
+
+	action, err := resolveURL(action, r.URL)
+	if err != nil {
+		return nil, "", err
+	}
+
+	req := &Request{URL: action, Referer: r.URL}
+	method, _ := attr(form, "method")
+
+	if strings.EqualFold(method, http.MethodPost) {
+		req.Method = http.MethodPost
+		req.Header = http.Header{
+			"Content-Type": {"application/x-www-form-urlencoded"},
+		}
+		req.Body = []byte(values.Encode())
+	} else {
+		// Like a browser, replace the action's query entirely
+		uri, err := url.Parse(action)
+		if err != nil {
+			return nil, "", err
+		}
+		uri.RawQuery = values.Encode()
+		req.URL = uri.String()
+	}
+
+	rc, err := Do(r.Context, r.Getter, req)
+	if err != nil {
+		return nil, "", err
+	}
+
+	defer rc.Close()
+	el, err := r.parse(req.URL, rc)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return el, req.URL, nil
+}
+
+// formValues collects the values a browser would send for
+// the form's controls, including hidden inputs like CSRF tokens
+func formValues(form *html.Node) url.Values {
+
+	values := url.Values{}
+	controls := css.MustCompile("input[name], textarea[name], select[name]")
+
+	for _, control := range controls.MatchAll(form) {
+
+		if _, err := attr(control, "disabled"); err == nil {
+			continue
+		}
+
+		name, _ := attr(control, "name")
+
+		switch control.Data {
+		case "textarea":
+			txt, _ := text(control)
+			values.Add(name, txt)
+		case "select":
+			if value, ok := selectedOption(control); ok {
+				values.Add(name, value)
+			}
+		default:
+			inputType, _ := attr(control, "type")
+			value, _ := attr(control, "value")
+
+			switch strings.ToLower(inputType) {
+			case "submit", "button", "image", "reset", "file":
+			case "checkbox", "radio":
+				if _, err := attr(control, "checked"); err == nil {
+					if value == "" {
+						value = "on"
+					}
+					values.Add(name, value)
+				}
+			default:
+				values.Add(name, value)
+			}
+		}
+	}
+
+	return values
+}
+
+// selectedOption returns the value of the selected
+// option, or the first option when none is selected
+func selectedOption(sel *html.Node) (string, bool) {
+
+	options := css.MustCompile("option").MatchAll(sel)
+	if len(options) == 0 {
+		return "", false
+	}
+
+	chosen := options[0]
+	for _, option := range options {
+		if _, err := attr(option, "selected"); err == nil {
+			chosen = option
+			break
+		}
+	}
+
+	if value, err := attr(chosen, "value"); err == nil {
+		return value, true
+	}
+	txt, _ := text(chosen)
+	return txt, true
+}
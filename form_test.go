@@ -0,0 +1,106 @@
+package scraper
+
+import (
+	"io"
+	"net/http"
+	"testing"
+)
+
+const loginFormHTML = `
+	<form id="login" action="/login" method="post">
+		<input type="hidden" name="csrf" value="token123">
+		<input type="text" name="user">
+		<input type="password" name="pass">
+		<input type="checkbox" name="remember" value="yes" checked>
+		<input type="checkbox" name="spam" value="yes">
+		<input type="text" name="ignored" value="x" disabled>
+		<input type="submit" name="go" value="Login">
+	</form>
+	<form id="search" action="/search">
+		<select name="sort">
+			<option value="new">New</option>
+			<option value="old" selected>Old</option>
+		</select>
+		<textarea name="q">default</textarea>
+	</form>`
+
+var formTestRoutes = testRoutes{
+	"/": func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, loginFormHTML)
+	},
+	"/login": func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Method != http.MethodPost ||
+			r.PostForm.Get("csrf") != "token123" ||
+			r.PostForm.Get("user") != "admin" ||
+			r.PostForm.Get("pass") != "secret" ||
+			r.PostForm.Get("remember") != "yes" ||
+			r.PostForm["spam"] != nil ||
+			r.PostForm["ignored"] != nil ||
+			r.PostForm["go"] != nil {
+			http.Error(w, "bad login", http.StatusForbidden)
+			return
+		}
+		io.WriteString(w, "<p>welcome admin</p>")
+	},
+	"/search": func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "<p>"+r.URL.Query().Get("q")+
+			" by "+r.URL.Query().Get("sort")+"</p>")
+	},
+}
+
+func TestSubmit_Post(t *testing.T) {
+
+	server := newTestServer(formTestRoutes)
+	defer server.Close()
+
+	results, err := New(server.URL, nil, HTTPGetter()).
+		Submit("#login", map[string]string{
+			"user": "admin",
+			"pass": "secret",
+		}).
+		Select(Sel{"value": "p"}).
+		Done()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0]["value"] != "welcome admin" {
+		t.Fatalf("Expected to be logged in, received %v", results)
+	}
+}
+
+func TestSubmit_Get(t *testing.T) {
+
+	server := newTestServer(formTestRoutes)
+	defer server.Close()
+
+	results, err := New(server.URL, nil, HTTPGetter()).
+		Submit("#search", map[string]string{"q": "gophers"}).
+		Select(Sel{"value": "p"}).
+		Done()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0]["value"] != "gophers by old" {
+		t.Fatalf("Expected search results, received %v", results)
+	}
+}
+
+func TestSubmit_Errors(t *testing.T) {
+
+	server := newTestServer(formTestRoutes)
+	defer server.Close()
+
+	if _, err := New(server.URL, nil, HTTPGetter()).
+		Submit("#missing", nil).Done(); err == nil {
+		t.Fatalf("Expected an error for a missing form")
+	}
+	if _, err := New(server.URL, nil, HTTPGetter()).
+		Submit("select", nil).Done(); err == nil {
+		t.Fatalf("Expected an error for a non-form element")
+	}
+	if _, err := New(server.URL, nil, HTTPGetter()).
+		Submit("#login", nil).Done(); err == nil {
+		t.Fatalf("Expected the failed login to be reported")
+	}
+}
@@ -1,8 +1,9 @@
 package scraper
 
 import (
-	"errors"
+	"context"
 	"io"
+	"io/fs"
 	"io/ioutil"
 	"net/http"
 	"net/url"
@@ -11,22 +12,36 @@ import (
 )
 
 // Getter is HTTP get abstraction to enable
-// a Scraper's data source to be changed
+// a Scraper's data source to be changed.
+// Getters that keep state between requests, such as
+// the RateLimitedGetter, RobotsGetter, RecordingGetter
+// and ArchivingGetter, guard it with a mutex so are
+// shared by pointer rather than copied.
 type Getter interface {
 	Get(url string, srcURL string) (io.ReadCloser, error)
 }
 
-type userAgent interface {
-	UserAgent() string
+// ContextGetter is a Getter whose requests can be
+// cancelled or bounded by the deadline of a context
+type ContextGetter interface {
+	Getter
+	GetContext(ctx context.Context, url string, srcURL string) (io.ReadCloser, error)
 }
 
-type httpGetter struct {
-	userAgent
+// Response is implemented by the bodies returned from
+// Getters that know how the data was originally served
+type Response interface {
+	io.ReadCloser
+	StatusCode() int
+	Header() http.Header
 }
 
-type multiUserAgent struct {
-	values []string
-	index  int
+// memoryResponse is a Response
+// for data already held in memory
+type memoryResponse struct {
+	io.Reader
+	status int
+	header http.Header
 }
 
 type urlResolver struct {
@@ -41,24 +56,22 @@ type MemoryGetter map[string]string
 // retrieves file data by specified url key.
 type FileGetter map[string]string
 
-// HTTPGetter create a Getter
-// that retrieves urls over http.
-func HTTPGetter() Getter {
-	return urlResolver{httpGetter{
-		&multiUserAgent{
-			values: []string{
-				"",
-			},
-		},
-	}}
-}
-
 // Get looks up the string data for the specifed url
 func (c MemoryGetter) Get(url string, srcURL string) (io.ReadCloser, error) {
+	return c.GetContext(context.Background(), url, srcURL)
+}
+
+// GetContext looks up the string data for the specifed
+// url, failing if the context is already done
+func (c MemoryGetter) GetContext(ctx context.Context, url string, srcURL string) (io.ReadCloser, error) {
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
 	data := c[url]
 	if data == "" {
-		return nil, errors.New("No data provided for url: " + url)
+		return nil, notFoundError("No data provided for url: " + url)
 	}
 
 	return ioutil.NopCloser(strings.NewReader(data)), nil
@@ -66,10 +79,20 @@ func (c MemoryGetter) Get(url string, srcURL string) (io.ReadCloser, error) {
 
 // Get looks up the file data for the specifed url
 func (c FileGetter) Get(url string, srcURL string) (io.ReadCloser, error) {
+	return c.GetContext(context.Background(), url, srcURL)
+}
+
+// GetContext looks up the file data for the specifed
+// url, failing if the context is already done
+func (c FileGetter) GetContext(ctx context.Context, url string, srcURL string) (io.ReadCloser, error) {
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
 	fileName := c[url]
 	if fileName == "" {
-		return nil, errors.New("No file provided for url: " + url)
+		return nil, notFoundError("No file provided for url: " + url)
 	}
 
 	file, err := os.Open(fileName)
@@ -81,43 +104,118 @@ func (c FileGetter) Get(url string, srcURL string) (io.ReadCloser, error) {
 }
 
 func (u urlResolver) Get(urlStr string, srcURL string) (io.ReadCloser, error) {
+	return u.GetContext(context.Background(), urlStr, srcURL)
+}
+
+func (u urlResolver) GetContext(ctx context.Context, urlStr string, srcURL string) (io.ReadCloser, error) {
 
 	if srcURL == "" {
-		return u.Getter.Get(urlStr, srcURL)
+		return getContext(ctx, u.Getter, urlStr, srcURL)
 	}
 
-	uri, err := url.Parse(urlStr)
+	resolved, err := resolveURL(urlStr, srcURL)
 	if err != nil {
 		return nil, err
 	}
 
+	// The source url is still sent along as the referer
+	req := requestFrom(ctx)
+	req.Referer = srcURL
+	return getContext(withRequest(ctx, &req), u.Getter, resolved, "")
+}
+
+func (u urlResolver) Do(ctx context.Context, req *Request) (io.ReadCloser, error) {
+
+	resolved := *req
+	if req.Referer != "" {
+		url, err := resolveURL(req.URL, req.Referer)
+		if err != nil {
+			return nil, err
+		}
+		resolved.URL = url
+	}
+	return Do(ctx, u.Getter, &resolved)
+}
+
+// resolveURL resolves a possibly relative url
+// against the url of the page it was found on
+func resolveURL(urlStr string, srcURL string) (string, error) {
+
+	uri, err := url.Parse(urlStr)
+	if err != nil {
+		return "", err
+	}
+	if srcURL == "" {
+		return uri.String(), nil
+	}
+
 	base, err := url.Parse(srcURL)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 
-	resolvedURI := base.ResolveReference(uri)
-	return u.Getter.Get(resolvedURI.String(), "")
+	return base.ResolveReference(uri).String(), nil
 }
 
-func (c httpGetter) Get(url string, srcURL string) (io.ReadCloser, error) {
+// hostOf returns the lowercased host (and
+// port) of a possibly relative url
+func hostOf(urlStr string, srcURL string) (string, error) {
 
-	req, err := http.NewRequest("GET", url, nil)
+	resolved, err := resolveURL(urlStr, srcURL)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 
-	req.Header.Add("UserAgent", c.UserAgent())
-	resp, err := http.DefaultClient.Do(req)
+	uri, err := url.Parse(resolved)
 	if err != nil {
+		return "", err
+	}
+
+	return strings.ToLower(uri.Host), nil
+}
+
+// getContext retrieves the url with the given getter, handing
+// it the context when the getter knows how to use one
+func getContext(ctx context.Context, g Getter, url string, srcURL string) (io.ReadCloser, error) {
+
+	if err := ctx.Err(); err != nil {
 		return nil, err
 	}
+	if cg, ok := g.(ContextGetter); ok {
+		return cg.GetContext(ctx, url, srcURL)
+	}
+	return g.Get(url, srcURL)
+}
+
+// responseHeader returns the headers the body was
+// served with, or nil when they are not known
+func responseHeader(r io.Reader) http.Header {
+	if resp, ok := r.(Response); ok {
+		return resp.Header()
+	}
+	return nil
+}
+
+func (r *memoryResponse) Close() error {
+	return nil
+}
+
+func (r *memoryResponse) StatusCode() int {
+	return r.status
+}
+
+func (r *memoryResponse) Header() http.Header {
+	return r.header
+}
+
+// notFoundError is returned by getters holding no data for a url,
+// and matches fs.ErrNotExist so it can be told apart from failures
+type notFoundError string
 
-	return resp.Body, nil
+func (e notFoundError) Error() string {
+	return string(e)
 }
 
-func (source *multiUserAgent) UserAgent() string {
-	value := source.values[source.index]
-	source.index = (source.index + 1) % len(source.values)
-	return value
+func (e notFoundError) Is(target error) bool {
+	return target == fs.ErrNotExist
 }
@@ -5,26 +5,6 @@ import (
 	"testing"
 )
 
-func TestMultiUserAgent(t *testing.T) {
-
-	agentStrings := []string{
-		"userAgent1", "userAgent2", "userAgent3",
-	}
-
-	agent := &multiUserAgent{
-		values: agentStrings,
-	}
-
-	for i := 0; i < len(agentStrings)*2; i++ {
-
-		expected := agentStrings[i%len(agentStrings)]
-		actual := agent.UserAgent()
-		if actual != expected {
-			t.Errorf("Expected %s, received %s", expected, actual)
-		}
-	}
-}
-
 func TestMemoryGetter(t *testing.T) {
 
 	url := "localhost"
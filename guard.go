@@ -0,0 +1,188 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"syscall"
+)
+
+// Guard stops an HTTPGetter from reaching addresses that pages
+// from untrusted sites should not be able to make it request,
+// such as loopback, private, link-local and cloud metadata
+// addresses. Addresses are checked when each connection is
+// dialed, after DNS resolution and for every redirect.
+type Guard struct {
+	// Schemes lists the url schemes that may be
+	// requested, defaulting to http and https
+	Schemes []string
+	// Allow lists host names, IPs or CIDR ranges
+	// that may be reached even though they are blocked
+	Allow []string
+}
+
+// GuardError is returned when a
+// Guard refuses to make a request
+type GuardError struct {
+	// Target is the url or host that was blocked
+	Target string
+	Reason string
+}
+
+// blockedNets are the ranges Go's net.IP has no method for
+var blockedNets = []*net.IPNet{
+	mustParseCIDR("100.64.0.0/10"), // carrier-grade NAT
+	mustParseCIDR("192.0.0.0/24"),  // IETF protocol assignments
+	mustParseCIDR("198.18.0.0/15"), // benchmarking
+}
+
+// HTTPGuard makes the Getter refuse requests the guard blocks,
+// failing with a GuardError. The transport must be an
+// *http.Transport, or every request fails. Proxies are dialed
+// instead of the hosts requested, so any set by HTTPProxy or
+// a ProxyPool must be on the Allow list, while proxies from
+// the environment are not used.
+func HTTPGuard(guard *Guard) HTTPOption {
+	return func(cfg *httpConfig) {
+		cfg.guard = guard
+	}
+}
+
+// checkURL checks the url has a scheme the guard allows
+func (g *Guard) checkURL(urlStr string) error {
+
+	uri, err := url.Parse(urlStr)
+	if err != nil {
+		return err
+	}
+
+	schemes := g.Schemes
+	if schemes == nil {
+		schemes = []string{"http", "https"}
+	}
+	for _, scheme := range schemes {
+		if strings.EqualFold(uri.Scheme, scheme) {
+			return nil
+		}
+	}
+
+	return &GuardError{urlStr, fmt.Sprintf("scheme %q is not allowed", uri.Scheme)}
+}
+
+// allowedHost reports whether the host
+// name is on the guard's allow list
+func (g *Guard) allowedHost(host string) bool {
+	for _, allow := range g.Allow {
+		if strings.EqualFold(allow, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowedIP reports whether the ip, or
+// a range holding it, is on the allow list
+func (g *Guard) allowedIP(ip net.IP) bool {
+	for _, allow := range g.Allow {
+		if _, allowNet, err := net.ParseCIDR(allow); err == nil && allowNet.Contains(ip) {
+			return true
+		}
+		if allowIP := net.ParseIP(allow); allowIP != nil && allowIP.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkProxies checks every proxy the Getter will dial is on the
+// allow list, as the hosts requested through them go unchecked
+func (g *Guard) checkProxies(proxy *url.URL, pool *ProxyPool) error {
+
+	proxies := []*url.URL{}
+	if proxy != nil {
+		proxies = append(proxies, proxy)
+	}
+	if pool != nil {
+		for _, p := range pool.proxies {
+			proxies = append(proxies, p.url)
+		}
+	}
+
+	for _, proxy := range proxies {
+		host := proxy.Hostname()
+		if ip := net.ParseIP(host); ip != nil && g.allowedIP(ip) {
+			continue
+		}
+		if !g.allowedHost(host) {
+			return fmt.Errorf("proxy %q is not on the allow list", proxy.Redacted())
+		}
+	}
+	return nil
+}
+
+// blocked reports whether the guard
+// refuses connections to the ip
+func (g *Guard) blocked(ip net.IP) bool {
+
+	if g.allowedIP(ip) {
+		return false
+	}
+
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return true
+	}
+	for _, blockedNet := range blockedNets {
+		if blockedNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// dialContext dials the address, checking every ip it resolves
+// to just before connecting so DNS cannot be used to sneak
+// past the guard between a check and the connection
+func (g *Guard) dialContext(dialer *net.Dialer) func(context.Context, string, string) (net.Conn, error) {
+
+	return func(ctx context.Context, network string, addr string) (net.Conn, error) {
+
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		if g.allowedHost(host) {
+			return dialer.DialContext(ctx, network, addr)
+		}
+
+		guarded := *dialer
+		guarded.Control = func(network string, address string, c syscall.RawConn) error {
+			ipStr, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(ipStr)
+			if ip == nil || g.blocked(ip) {
+				return &GuardError{host, fmt.Sprintf(
+					"%s is not a public address", ipStr)}
+			}
+			return nil
+		}
+		return guarded.DialContext(ctx, network, addr)
+	}
+}
+
+func (e *GuardError) Error() string {
+	return fmt.Sprintf("Request to %q blocked: %s", e.Target, e.Reason)
+}
+
+func mustParseCIDR(cidr string) *net.IPNet {
+	_, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		panic(err)
+	}
+	return ipNet
+}
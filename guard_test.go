@@ -0,0 +1,132 @@
+package scraper
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+type GuardTest struct {
+	IP      string
+	Blocked bool
+}
+
+var guardTests = []GuardTest{
+	GuardTest{"169.254.169.254", true},
+	GuardTest{"127.0.0.1", true},
+	GuardTest{"10.0.0.1", true},
+	GuardTest{"192.168.1.1", true},
+	GuardTest{"100.64.1.1", true},
+	GuardTest{"0.0.0.0", true},
+	GuardTest{"::1", true},
+	GuardTest{"fd00::1", true},
+	GuardTest{"fe80::1", true},
+	GuardTest{"8.8.8.8", false},
+	GuardTest{"2001:4860:4860::8888", false},
+}
+
+func TestGuard_Blocked(t *testing.T) {
+
+	guard := &Guard{}
+	for _, test := range guardTests {
+		if blocked := guard.blocked(net.ParseIP(test.IP)); blocked != test.Blocked {
+			t.Errorf("%+v: Received blocked %v", test, blocked)
+		}
+	}
+
+	guard = &Guard{Allow: []string{"10.0.0.0/8", "::1"}}
+	if guard.blocked(net.ParseIP("10.1.2.3")) || guard.blocked(net.ParseIP("::1")) {
+		t.Errorf("Expected allowed addresses not to be blocked")
+	}
+	if !guard.blocked(net.ParseIP("127.0.0.1")) {
+		t.Errorf("Expected 127.0.0.1 to still be blocked")
+	}
+}
+
+func TestGuard_HTTP(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, `<p class="name">Guarded</p>`)
+		}))
+	defer server.Close()
+
+	getter := HTTPGetter(HTTPGuard(&Guard{}))
+	_, err := New(server.URL, nil, getter).Done()
+
+	var guardErr *GuardError
+	if !errors.As(err, &guardErr) {
+		t.Fatalf("Expected a GuardError, received %v", err)
+	}
+
+	getter = HTTPGetter(HTTPGuard(&Guard{Allow: []string{"127.0.0.0/8"}}))
+	data, err := New(server.URL, nil, getter).Select(Sel{"name": ".name"}).Done()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 1 || data[0]["name"] != "Guarded" {
+		t.Errorf("Unexpected data %v", data)
+	}
+}
+
+func TestGuard_Scheme(t *testing.T) {
+
+	getter := HTTPGetter(HTTPGuard(&Guard{}))
+	_, err := New("file:///etc/passwd", nil, getter).Done()
+
+	var guardErr *GuardError
+	if !errors.As(err, &guardErr) || guardErr.Target != "file:///etc/passwd" {
+		t.Fatalf("Expected a GuardError, received %v", err)
+	}
+}
+
+func TestGuard_Follow(t *testing.T) {
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, `<a href="`+server.URL+`/internal">internal</a>`)
+		}))
+	defer server.Close()
+
+	start := strings.Replace(server.URL, "127.0.0.1", "localhost", 1)
+	getter := HTTPGetter(HTTPGuard(&Guard{Allow: []string{"localhost"}}))
+
+	_, err := New(start, nil, getter).Follow("a[href]").Done()
+
+	var guardErr *GuardError
+	if !errors.As(err, &guardErr) || guardErr.Target != "127.0.0.1" {
+		t.Fatalf("Expected a GuardError for the followed page, received %v", err)
+	}
+}
+
+func TestGuard_Unguardable(t *testing.T) {
+
+	transport := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		t.Errorf("Unexpected unguarded request to %v", r.URL)
+		return nil, errors.New("Unguarded")
+	})
+	proxy, _ := url.Parse("http://proxy.example.com:8080")
+
+	for _, getter := range []Getter{
+		HTTPGetter(HTTPTransport(transport), HTTPGuard(&Guard{})),
+		HTTPGetter(HTTPProxy(proxy), HTTPGuard(&Guard{})),
+	} {
+		_, err := getter.Get("http://example.com/", "")
+
+		var guardErr *GuardError
+		if !errors.As(err, &guardErr) {
+			t.Errorf("Expected a GuardError, received %v", err)
+		}
+	}
+
+	getter := HTTPGetter(HTTPProxy(proxy), HTTPGuard(&Guard{Allow: []string{"proxy.example.com"}}))
+	if httpGetter := getter.(urlResolver).Getter.(httpGetter); httpGetter.err != nil {
+		t.Errorf("Expected an allowed proxy to be used, received %v", httpGetter.err)
+	}
+}
@@ -0,0 +1,387 @@
+package scraper
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// RecordingGetter is a Getter that writes every request made
+// through it, and the response received, to a HAR file so a
+// scrape can be replayed later with a HARGetter. The entries
+// are kept until Close writes the file.
+type RecordingGetter struct {
+	Getter
+	// Path is the HAR file the requests are written to
+	Path string
+
+	mu      sync.Mutex
+	entries []harEntry
+}
+
+// HARGetter is a Getter that serves the responses stored in
+// a HAR file, such as one written by a RecordingGetter. A url
+// requested more than once is answered with its recorded
+// responses in order, repeating the last one when they run out.
+type HARGetter struct {
+	// StatusPolicy decides which recorded statuses are
+	// returned as pages, defaulting to DefaultStatusPolicy
+	StatusPolicy StatusPolicy
+
+	mu      sync.Mutex
+	entries map[string][]harEntry
+	served  map[string]int
+}
+
+type harFile struct {
+	Log harLog `json:"log"`
+}
+
+type harLog struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Entries []harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime time.Time   `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// Get retrieves the url and records it
+func (g *RecordingGetter) Get(url string, srcURL string) (io.ReadCloser, error) {
+	return g.GetContext(context.Background(), url, srcURL)
+}
+
+// GetContext retrieves the url with the wrapped
+// Getter and records the request and its response
+func (g *RecordingGetter) GetContext(ctx context.Context, url string, srcURL string) (io.ReadCloser, error) {
+
+	resolved, err := resolveURL(url, srcURL)
+	if err != nil {
+		return nil, err
+	}
+
+	req := requestFrom(ctx)
+	if req.Referer == "" {
+		req.Referer = srcURL
+	}
+	req.URL = resolved
+
+	started := time.Now()
+	rc, err := getContext(ctx, g.Getter, url, srcURL)
+
+	// Unaccepted statuses are recorded too so
+	// a replay skips or fails the same pages
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		g.record(newHAREntry(&req, started, statusErr.StatusCode,
+			statusErr.Header, statusErr.Body))
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	defer rc.Close()
+	body, err := ioutil.ReadAll(limitBody(ctx, resolved, rc))
+	if err != nil {
+		return nil, err
+	}
+
+	status := http.StatusOK
+	if resp, ok := rc.(Response); ok {
+		status = resp.StatusCode()
+	}
+	header := responseHeader(rc)
+
+	g.record(newHAREntry(&req, started, status, header, body))
+
+	return &memoryResponse{bytes.NewReader(body), status, header}, nil
+}
+
+// Close writes the requests recorded so far to the HAR file.
+// Later requests are still recorded, for another Close to write.
+func (g *RecordingGetter) Close() error {
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	data, err := json.MarshalIndent(harFile{harLog{
+		"1.2", harCreator{"scraper", "1.0"}, g.entries,
+	}}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFile(g.Path, data)
+}
+
+// record adds the entry to those written by Close
+func (g *RecordingGetter) record(entry harEntry) {
+	g.mu.Lock()
+	g.entries = append(g.entries, entry)
+	g.mu.Unlock()
+}
+
+// LoadHAR creates a HARGetter serving
+// the responses in the HAR file at path
+func LoadHAR(path string) (*HARGetter, error) {
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var har harFile
+	if err := json.Unmarshal(data, &har); err != nil {
+		return nil, err
+	}
+
+	g := &HARGetter{
+		entries: make(map[string][]harEntry),
+		served:  make(map[string]int),
+	}
+	for _, entry := range har.Log.Entries {
+		key := harKey(entry.Request.Method, entry.Request.URL, entry.Request.PostData)
+		g.entries[key] = append(g.entries[key], entry)
+	}
+
+	return g, nil
+}
+
+// Get looks up the recorded response for the url
+func (g *HARGetter) Get(url string, srcURL string) (io.ReadCloser, error) {
+	return g.GetContext(context.Background(), url, srcURL)
+}
+
+// GetContext looks up the recorded response for the url,
+// failing if the context is already done
+func (g *HARGetter) GetContext(ctx context.Context, url string, srcURL string) (io.ReadCloser, error) {
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	resolved, err := resolveURL(url, srcURL)
+	if err != nil {
+		return nil, err
+	}
+
+	req := requestFrom(ctx)
+	req.URL = resolved
+	key := harKey(req.Method, resolved, harPost(&req))
+
+	g.mu.Lock()
+	entries := g.entries[key]
+	i := g.served[key]
+	if i < len(entries)-1 {
+		g.served[key] = i + 1
+	}
+	g.mu.Unlock()
+
+	if len(entries) == 0 {
+		return nil, notFoundError("No HAR entry provided for url: " + resolved)
+	}
+
+	resp := entries[i].Response
+	body, err := resp.Content.body()
+	if err != nil {
+		return nil, err
+	}
+
+	header := http.Header{}
+	for _, h := range resp.Headers {
+		header.Add(h.Name, h.Value)
+	}
+
+	policy := g.StatusPolicy
+	if policy == nil {
+		policy = DefaultStatusPolicy
+	}
+	if action := policy(resp.Status); action != StatusAccept {
+		if len(body) > maxStatusErrorBody {
+			body = body[:maxStatusErrorBody]
+		}
+		return nil, &HTTPStatusError{resolved, resp.Status, header, body, action}
+	}
+
+	return &memoryResponse{bytes.NewReader(body), resp.Status, header}, nil
+}
+
+func newHAREntry(req *Request, started time.Time,
+	status int, header http.Header, body []byte) harEntry {
+
+	method := req.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	reqHeader := req.Header.Clone()
+	if reqHeader == nil {
+		reqHeader = http.Header{}
+	}
+	if req.Referer != "" {
+		reqHeader.Set("Referer", req.Referer)
+	}
+
+	var query []harNameValue
+	if uri, err := url.Parse(req.URL); err == nil {
+		query = harValues(uri.Query())
+	}
+
+	content := harContent{Size: len(body), MimeType: header.Get("Content-Type")}
+	if utf8.Valid(body) {
+		content.Text = string(body)
+	} else {
+		content.Text = base64.StdEncoding.EncodeToString(body)
+		content.Encoding = "base64"
+	}
+
+	elapsed := float64(time.Since(started)) / float64(time.Millisecond)
+
+	return harEntry{
+		StartedDateTime: started,
+		Time:            elapsed,
+		Request: harRequest{
+			Method:      method,
+			URL:         req.URL,
+			HTTPVersion: "HTTP/1.1",
+			Cookies:     []harNameValue{},
+			Headers:     harValues(reqHeader),
+			QueryString: query,
+			PostData:    harPost(req),
+			HeadersSize: -1,
+			BodySize:    len(req.Body),
+		},
+		Response: harResponse{
+			Status:      status,
+			StatusText:  http.StatusText(status),
+			HTTPVersion: "HTTP/1.1",
+			Cookies:     []harNameValue{},
+			Headers:     harValues(header),
+			Content:     content,
+			RedirectURL: header.Get("Location"),
+			HeadersSize: -1,
+			BodySize:    len(body),
+		},
+		Timings: harTimings{Send: 0, Wait: elapsed, Receive: 0},
+	}
+}
+
+// harKey identifies the requests a recorded response answers
+func harKey(method string, url string, post *harPostData) string {
+
+	if method == "" {
+		method = http.MethodGet
+	}
+	key := method + " " + url
+	if post != nil {
+		key += "\n" + post.Text
+	}
+	return key
+}
+
+func harPost(req *Request) *harPostData {
+
+	if req.Body == nil {
+		return nil
+	}
+	return &harPostData{req.Header.Get("Content-Type"), string(req.Body)}
+}
+
+// harValues flattens headers or query values
+// into the sorted name/value pairs of a HAR file
+func harValues(values map[string][]string) []harNameValue {
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := []harNameValue{}
+	for _, name := range names {
+		for _, value := range values[name] {
+			pairs = append(pairs, harNameValue{name, value})
+		}
+	}
+	return pairs
+}
+
+func (c harContent) body() ([]byte, error) {
+
+	switch c.Encoding {
+	case "":
+		return []byte(c.Text), nil
+	case "base64":
+		return base64.StdEncoding.DecodeString(c.Text)
+	}
+	return nil, fmt.Errorf("Unsupported HAR content encoding %q", c.Encoding)
+}
@@ -0,0 +1,108 @@
+package scraper
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+var harTestRoutes = testRoutes{
+	"/": func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `<a href="/one">1</a><a href="/missing">2</a><a href="/two">3</a>`)
+	},
+	"/one": func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "<p>one</p>")
+	},
+	"/two": func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<p>two</p>\xff"))
+	},
+}
+
+func TestHAR_RecordReplay(t *testing.T) {
+
+	server := newTestServer(harTestRoutes)
+	path := filepath.Join(t.TempDir(), "scrape.har")
+
+	recorder := &RecordingGetter{Getter: HTTPGetter(), Path: path}
+	recorded, err := New(server.URL, nil, recorder).
+		Follow("a[href]").
+		Select(Sel{"value": "p"}).
+		Done()
+	server.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := recorder.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if len(recorded) != 2 {
+		t.Fatalf("Expected 2 results, received %v", recorded)
+	}
+
+	replayer, err := LoadHAR(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayed, err := New(server.URL, nil, replayer).
+		Follow("a[href]").
+		Select(Sel{"value": "p"}).
+		Done()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(recorded, replayed) {
+		t.Fatalf("Expected %v to be replayed, received %v", recorded, replayed)
+	}
+
+	_, err = replayer.Get(server.URL+"/missing", "")
+	var statusErr *HTTPStatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound {
+		t.Fatalf("Expected the recorded 404, received %v", err)
+	}
+
+	if _, err := replayer.Get(server.URL+"/other", ""); err == nil {
+		t.Fatalf("Expected an error for a url that was not recorded")
+	}
+}
+
+func TestHAR_Submit(t *testing.T) {
+
+	server := newTestServer(formTestRoutes)
+	path := filepath.Join(t.TempDir(), "login.har")
+	fields := map[string]string{"user": "admin", "pass": "secret"}
+
+	recorder := &RecordingGetter{Getter: HTTPGetter(), Path: path}
+	_, err := New(server.URL, nil, recorder).Submit("#login", fields).Done()
+	server.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := recorder.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	replayer, err := LoadHAR(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err := New(server.URL, nil, replayer).
+		Submit("#login", fields).
+		Select(Sel{"value": "p"}).
+		Done()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0]["value"] != "welcome admin" {
+		t.Fatalf("Expected the recorded login, received %v", results)
+	}
+
+	// A post with different data was never recorded
+	fields["pass"] = "wrong"
+	_, err = New(server.URL, nil, replayer).Submit("#login", fields).Done()
+	if err == nil {
+		t.Fatalf("Expected an error for a different login")
+	}
+}
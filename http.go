@@ -0,0 +1,454 @@
+package scraper
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// maxStatusErrorBody is how much of an unaccepted
+// response's body is kept in its HTTPStatusError
+const maxStatusErrorBody = 1024
+
+// StatusAction is what a StatusPolicy
+// decides to do with an HTTP response
+type StatusAction int
+
+const (
+	// StatusAccept parses the response as a page
+	StatusAccept StatusAction = iota
+	// StatusSkip leaves the page out of the results of
+	// Follow, but still fails the scrape's starting page
+	StatusSkip
+	// StatusFail stops the scrape, with Done
+	// returning the page's HTTPStatusError
+	StatusFail
+)
+
+// StatusPolicy decides which status codes
+// count as a successful response
+type StatusPolicy func(statusCode int) StatusAction
+
+// HTTPStatusError is returned by the HTTPGetter when
+// the status policy does not accept a response
+type HTTPStatusError struct {
+	URL        string
+	StatusCode int
+	Header     http.Header
+	// Body holds the start of the response body
+	Body   []byte
+	Action StatusAction
+}
+
+// HTTPOption configures the
+// Getter created by HTTPGetter
+type HTTPOption func(*httpConfig)
+
+type httpConfig struct {
+	client         *http.Client
+	transport      http.RoundTripper
+	timeout        time.Duration
+	maxRedirects   int
+	limitRedirects bool
+	certificates   []tls.Certificate
+	proxy          *url.URL
+	jar            http.CookieJar
+	statusPolicy   StatusPolicy
+	authorization  string
+	authHosts      []string
+	profiles       *Profiles
+	proxyPool      *ProxyPool
+	guard          *Guard
+}
+
+type httpGetter struct {
+	profiles      *Profiles
+	client        *http.Client
+	statusPolicy  StatusPolicy
+	authorization string
+	authHosts     []string
+	guard         *Guard
+	// err is why the guard could not be installed,
+	// failing every request rather than letting
+	// them through unguarded
+	err error
+}
+
+// httpResponse is the Response
+// returned by the httpGetter
+type httpResponse struct {
+	resp *http.Response
+	body io.Reader
+}
+
+// HTTPGetter create a Getter
+// that retrieves urls over http.
+func HTTPGetter(opts ...HTTPOption) Getter {
+
+	cfg := &httpConfig{statusPolicy: DefaultStatusPolicy}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	client, err := cfg.newClient()
+	return urlResolver{httpGetter{
+		cfg.profiles,
+		client,
+		cfg.statusPolicy,
+		cfg.authorization,
+		cfg.authHosts,
+		cfg.guard,
+		err,
+	}}
+}
+
+// DefaultStatusPolicy accepts 2xx responses
+// and skips pages with any other status
+func DefaultStatusPolicy(statusCode int) StatusAction {
+	if statusCode >= 200 && statusCode < 300 {
+		return StatusAccept
+	}
+	return StatusSkip
+}
+
+// HTTPStatusPolicy sets the policy deciding which response
+// status codes are parsed, skipped or fail the scrape
+func HTTPStatusPolicy(policy StatusPolicy) HTTPOption {
+	return func(cfg *httpConfig) {
+		cfg.statusPolicy = policy
+	}
+}
+
+// HTTPBasicAuth sends the HTTP Basic credentials given with
+// requests to the hosts listed or, when there are none, to
+// the host of the scrape's starting url, so they never leak
+// to the other sites a scrape follows links to
+func HTTPBasicAuth(username string, password string, hosts ...string) HTTPOption {
+	return func(cfg *httpConfig) {
+		req := &http.Request{Header: http.Header{}}
+		req.SetBasicAuth(username, password)
+		cfg.authorization = req.Header.Get("Authorization")
+		cfg.authHosts = hosts
+	}
+}
+
+// HTTPBearerToken sends the token as the Bearer authorization of
+// requests to the hosts listed or, when there are none, to the
+// host of the scrape's starting url
+func HTTPBearerToken(token string, hosts ...string) HTTPOption {
+	return func(cfg *httpConfig) {
+		cfg.authorization = "Bearer " + token
+		cfg.authHosts = hosts
+	}
+}
+
+// HTTPClient makes the Getter send its requests with the given
+// client instead of http.DefaultClient. The client is copied,
+// so the other options never modify the one passed in.
+func HTTPClient(client *http.Client) HTTPOption {
+	return func(cfg *httpConfig) {
+		cfg.client = client
+	}
+}
+
+// HTTPTransport sets the RoundTripper used to send requests
+func HTTPTransport(transport http.RoundTripper) HTTPOption {
+	return func(cfg *httpConfig) {
+		cfg.transport = transport
+	}
+}
+
+// HTTPTimeout limits the total time taken by each request,
+// including connecting, redirects and reading the body
+func HTTPTimeout(timeout time.Duration) HTTPOption {
+	return func(cfg *httpConfig) {
+		cfg.timeout = timeout
+	}
+}
+
+// HTTPMaxRedirects limits how many redirects are followed for
+// a single request, after which the redirect response itself
+// is returned to the status policy. Zero disables following
+// redirects.
+func HTTPMaxRedirects(max int) HTTPOption {
+	return func(cfg *httpConfig) {
+		cfg.maxRedirects = max
+		cfg.limitRedirects = true
+	}
+}
+
+// HTTPCertificates sets the TLS client certificates presented
+// to servers. Only applies when the transport is an *http.Transport.
+func HTTPCertificates(certs ...tls.Certificate) HTTPOption {
+	return func(cfg *httpConfig) {
+		cfg.certificates = append(cfg.certificates, certs...)
+	}
+}
+
+// HTTPProxy sends every request through the given proxy
+// URL. Only applies when the transport is an *http.Transport.
+func HTTPProxy(proxyURL *url.URL) HTTPOption {
+	return func(cfg *httpConfig) {
+		cfg.proxy = proxyURL
+	}
+}
+
+func (cfg *httpConfig) newClient() (*http.Client, error) {
+
+	if cfg.client == nil &&
+		cfg.transport == nil &&
+		cfg.timeout == 0 &&
+		!cfg.limitRedirects &&
+		cfg.certificates == nil &&
+		cfg.proxy == nil &&
+		cfg.proxyPool == nil &&
+		cfg.guard == nil &&
+		cfg.jar == nil {
+		return http.DefaultClient, nil
+	}
+
+	client := &http.Client{}
+	if cfg.client != nil {
+		*client = *cfg.client
+	}
+	if cfg.transport != nil {
+		client.Transport = cfg.transport
+	}
+	if cfg.timeout > 0 {
+		client.Timeout = cfg.timeout
+	}
+	if cfg.jar != nil {
+		client.Jar = cfg.jar
+	}
+	if cfg.limitRedirects {
+		max := cfg.maxRedirects
+		client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			if len(via) > max {
+				return http.ErrUseLastResponse
+			}
+			return nil
+		}
+	}
+
+	if cfg.certificates == nil &&
+		cfg.proxy == nil &&
+		cfg.proxyPool == nil &&
+		cfg.guard == nil {
+		return client, nil
+	}
+
+	transport := client.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	t, ok := transport.(*http.Transport)
+	if !ok {
+		if cfg.guard != nil {
+			return nil, fmt.Errorf("a %T transport can't be guarded", transport)
+		}
+		return client, nil
+	}
+
+	t = t.Clone()
+	if cfg.certificates != nil {
+		if t.TLSClientConfig == nil {
+			t.TLSClientConfig = &tls.Config{}
+		}
+		t.TLSClientConfig.Certificates = cfg.certificates
+	}
+	if cfg.proxy != nil {
+		t.Proxy = http.ProxyURL(cfg.proxy)
+	}
+
+	if cfg.guard != nil {
+		if err := cfg.guard.checkProxies(cfg.proxy, cfg.proxyPool); err != nil {
+			return nil, err
+		}
+		// A proxy from the environment would be dialed instead
+		// of the hosts requested, hiding them from the guard
+		if cfg.proxy == nil {
+			t.Proxy = nil
+		}
+		t.DialContext = cfg.guard.dialContext(&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		})
+	}
+
+	client.Transport = t
+	if cfg.proxyPool != nil {
+		t.Proxy = proxyFromContext
+		client.Transport = &poolTransport{cfg.proxyPool, t}
+	}
+	return client, nil
+}
+
+func (c httpGetter) Get(url string, srcURL string) (io.ReadCloser, error) {
+	return c.GetContext(context.Background(), url, srcURL)
+}
+
+func (c httpGetter) GetContext(ctx context.Context, url string, srcURL string) (io.ReadCloser, error) {
+
+	req := requestFrom(ctx)
+	req.URL = url
+	if srcURL != "" {
+		req.Referer = srcURL
+	}
+	return c.Do(ctx, &req)
+}
+
+func (c httpGetter) Do(ctx context.Context, r *Request) (io.ReadCloser, error) {
+
+	url, err := r.target()
+	if err != nil {
+		return nil, err
+	}
+
+	if c.err != nil {
+		return nil, &GuardError{url, c.err.Error()}
+	}
+	if c.guard != nil {
+		if err := c.guard.checkURL(url); err != nil {
+			return nil, err
+		}
+	}
+
+	method := r.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	var reqBody io.Reader
+	if r.Body != nil {
+		reqBody = bytes.NewReader(r.Body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.profiles != nil {
+		profile := c.profiles.Next(req.URL.Hostname())
+		for name, value := range profile.Header {
+			req.Header.Set(name, value)
+		}
+	}
+	if c.authorization != "" && c.authorizes(req.URL, startFrom(ctx)) {
+		req.Header.Set("Authorization", c.authorization)
+	}
+	if r.Referer != "" {
+		req.Header.Set("Referer", r.Referer)
+	}
+	for name, values := range r.Header {
+		req.Header[name] = values
+	}
+
+	// Ask for gzip ourselves, rather than leaving it to the
+	// transport, so decompression can be held to the Limits
+	gunzip := req.Header.Get("Accept-Encoding") == ""
+	if gunzip {
+		req.Header.Set("Accept-Encoding", "gzip")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = captureWARCWire(ctx, resp)
+
+	action := c.statusPolicy(resp.StatusCode)
+	if action == StatusAccept {
+		if gunzip && strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+			return gunzipResponse(ctx, url, resp)
+		}
+		return httpResponse{resp, resp.Body}, nil
+	}
+
+	defer resp.Body.Close()
+	body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxStatusErrorBody))
+	return nil, &HTTPStatusError{
+		url, resp.StatusCode, resp.Header, body, action,
+	}
+}
+
+// authorizes reports whether the credentials are sent to the
+// url, which outside of a scrape is any url requested directly
+func (c httpGetter) authorizes(uri *url.URL, start string) bool {
+
+	hosts := c.authHosts
+	if len(hosts) == 0 {
+		if start == "" {
+			return true
+		}
+		startURI, err := url.Parse(start)
+		if err != nil {
+			return false
+		}
+		hosts = []string{startURI.Host}
+	}
+
+	for _, host := range hosts {
+		if strings.EqualFold(host, uri.Host) || strings.EqualFold(host, uri.Hostname()) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("Unexpected status %v %q getting url %q",
+		e.StatusCode, http.StatusText(e.StatusCode), e.URL)
+}
+
+// gunzipResponse decompresses a gzipped response the way the
+// transport would have, failing with a LimitError if the body
+// grows past the MaxRatio of the scrape's Limits
+func gunzipResponse(ctx context.Context, url string, resp *http.Response) (io.ReadCloser, error) {
+
+	compressed := &countingReader{Reader: resp.Body}
+	zr, err := gzip.NewReader(compressed)
+	if err == io.EOF {
+		return httpResponse{resp, strings.NewReader("")}, nil
+	}
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+
+	var body io.Reader = zr
+	if max := limitsFrom(ctx).MaxRatio; max > 0 {
+		body = &ratioReader{body, compressed, 0, &LimitError{url, "MaxRatio", 0, max}}
+	}
+	return httpResponse{resp, body}, nil
+}
+
+func (r httpResponse) Read(p []byte) (int, error) {
+	return r.body.Read(p)
+}
+
+func (r httpResponse) Close() error {
+	return r.resp.Body.Close()
+}
+
+func (r httpResponse) StatusCode() int {
+	return r.resp.StatusCode
+}
+
+func (r httpResponse) Header() http.Header {
+	return r.resp.Header
+}
@@ -0,0 +1,235 @@
+package scraper
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHTTPGetter_Timeout(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second):
+			}
+		}))
+	defer server.Close()
+
+	getter := HTTPGetter(HTTPTimeout(50 * time.Millisecond))
+	if _, err := getter.Get(server.URL, ""); err == nil {
+		t.Fatalf("Expected the request to time out")
+	}
+}
+
+func TestHTTPGetter_MaxRedirects(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/3" {
+				io.WriteString(w, "done")
+				return
+			}
+			next := map[string]string{"/": "/1", "/1": "/2", "/2": "/3"}
+			http.Redirect(w, r, next[r.URL.Path], http.StatusFound)
+		}))
+	defer server.Close()
+
+	verifyGetter(t, HTTPGetter(HTTPMaxRedirects(3)), server.URL, "done")
+
+	for _, max := range []int{2, 0} {
+		_, err := HTTPGetter(HTTPMaxRedirects(max)).Get(server.URL, "")
+		var statusErr *HTTPStatusError
+		if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusFound {
+			t.Fatalf("Expected the redirect after %v redirects, received %v", max, err)
+		}
+	}
+}
+
+func TestHTTPGetter_Transport(t *testing.T) {
+
+	transport := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader(r.URL.String())),
+			Request:    r,
+		}, nil
+	})
+
+	client := &http.Client{Timeout: time.Second}
+	getter := HTTPGetter(HTTPClient(client), HTTPTransport(transport))
+	verifyGetter(t, getter, "http://localhost/page", "http://localhost/page")
+
+	if client.Transport != nil {
+		t.Fatalf("The client passed in should not be modified")
+	}
+}
+
+func TestHTTPGetter_Proxy(t *testing.T) {
+
+	proxy := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, "proxied "+r.URL.String())
+		}))
+	defer proxy.Close()
+
+	proxyURL, err := url.Parse(proxy.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	getter := HTTPGetter(HTTPProxy(proxyURL))
+	verifyGetter(t, getter, "http://example.com/page",
+		"proxied http://example.com/page")
+}
+
+func TestHTTPGetter_StatusError(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Test", "value")
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, strings.Repeat("x", maxStatusErrorBody*2))
+		}))
+	defer server.Close()
+
+	_, err := HTTPGetter().Get(server.URL, "")
+	statusErr, ok := err.(*HTTPStatusError)
+	if !ok {
+		t.Fatalf("Expected an HTTPStatusError, received %v", err)
+	}
+	if statusErr.StatusCode != http.StatusNotFound ||
+		statusErr.URL != server.URL ||
+		statusErr.Header.Get("X-Test") != "value" ||
+		statusErr.Action != StatusSkip {
+		t.Fatalf("Unexpected error values: %+v", statusErr)
+	}
+	if len(statusErr.Body) != maxStatusErrorBody {
+		t.Fatalf("Expected a body of %v bytes, received %v",
+			maxStatusErrorBody, len(statusErr.Body))
+	}
+
+	getter := HTTPGetter(HTTPStatusPolicy(func(statusCode int) StatusAction {
+		return StatusAccept
+	}))
+	verifyGetter(t, getter, server.URL,
+		strings.Repeat("x", maxStatusErrorBody*2))
+}
+
+func TestHTTPGetter_StatusPolicyFollow(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/":
+				io.WriteString(w, `<a href="/ok">ok</a><a href="/bad">bad</a>`)
+			case "/ok":
+				io.WriteString(w, "<p>found</p>")
+			default:
+				w.WriteHeader(http.StatusInternalServerError)
+				io.WriteString(w, "<p>error page</p>")
+			}
+		}))
+	defer server.Close()
+
+	results, err := New(server.URL, nil, HTTPGetter()).
+		Follow("a[href]").
+		Select(Sel{"value": "p"}).
+		Done()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0]["value"] != "found" {
+		t.Fatalf("Expected the error page to be skipped: %v", results)
+	}
+
+	getter := HTTPGetter(HTTPStatusPolicy(func(statusCode int) StatusAction {
+		if statusCode >= 500 {
+			return StatusFail
+		}
+		return DefaultStatusPolicy(statusCode)
+	}))
+	_, err = New(server.URL, nil, getter).Follow("a[href]").Done()
+	if statusErr, ok := err.(*HTTPStatusError); !ok ||
+		statusErr.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("Expected a 500 HTTPStatusError, received %v", err)
+	}
+}
+
+func TestHTTPGetter_Auth(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, r.Header.Get("Authorization"))
+		}))
+	defer server.Close()
+
+	verifyGetter(t, HTTPGetter(HTTPBasicAuth("user", "pass")),
+		server.URL, "Basic dXNlcjpwYXNz")
+	verifyGetter(t, HTTPGetter(HTTPBearerToken("token")),
+		server.URL, "Bearer token")
+}
+
+func TestHTTPGetter_AuthHosts(t *testing.T) {
+
+	handler := http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, `<p>`+r.Header.Get("Authorization")+`</p>`)
+		})
+	other := httptest.NewServer(handler)
+	defer other.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, `<a href="`+other.URL+`">other</a><p>`+
+				r.Header.Get("Authorization")+`</p>`)
+		}))
+	defer server.Close()
+
+	otherHost := strings.TrimPrefix(other.URL, "http://")
+	tests := []struct {
+		Getter Getter
+		Exp    []map[string]string
+	}{
+		// Credentials only go to the starting page's host
+		{HTTPGetter(HTTPBearerToken("token")),
+			[]map[string]string{{"auth": "Bearer token"}, {"auth": ""}}},
+		// Or to the hosts listed
+		{HTTPGetter(HTTPBasicAuth("user", "pass", otherHost)),
+			[]map[string]string{{"auth": ""}, {"auth": "Basic dXNlcjpwYXNz"}}},
+	}
+
+	for _, test := range tests {
+
+		start, err := New(server.URL, nil, test.Getter).Select(Sel{"auth": "p"}).Done()
+		if err != nil {
+			t.Fatal(err)
+		}
+		followed, err := New(server.URL, nil, test.Getter).
+			Follow("a[href]").
+			Select(Sel{"auth": "p"}).
+			Done()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		results := append(start, followed...)
+		if !reflect.DeepEqual(results, test.Exp) {
+			t.Errorf("Expected %v, received %v", test.Exp, results)
+		}
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
@@ -0,0 +1,157 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"golang.org/x/net/html"
+)
+
+// ratioFloor is the least compressed size a MaxRatio
+// is measured against, so small pages that compress
+// very well are not mistaken for decompression bombs
+const ratioFloor = 1024
+
+// Limits caps the resources a single page may use while
+// it is parsed. Zero values leave that resource unlimited.
+type Limits struct {
+	// MaxBodyBytes caps the size of a page's
+	// body once it has been decompressed
+	MaxBodyBytes int64
+	// MaxNodes caps the number of nodes in a page's parsed
+	// document. It is checked after parsing, so pair it
+	// with MaxBodyBytes to also bound the memory used.
+	MaxNodes int
+	// MaxRatio caps how many times larger a compressed
+	// body may grow when it is decompressed
+	MaxRatio float64
+}
+
+// LimitError is returned when a page
+// goes over one of the scraper's Limits
+type LimitError struct {
+	URL string
+	// Limit is the name of the Limits field that was hit
+	Limit string
+	// Max is the value of a MaxBodyBytes or MaxNodes limit
+	Max int64
+	// Ratio is the value of a MaxRatio limit
+	Ratio float64
+}
+
+type limitsKey struct{}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	io.Reader
+	n int64
+}
+
+// ratioReader fails once more than the limit's ratio
+// of data has been decompressed from the compressed reader
+type ratioReader struct {
+	io.Reader
+	compressed *countingReader
+	n          int64
+	err        *LimitError
+}
+
+// bodyLimitReader fails once more
+// than the limit has been read
+type bodyLimitReader struct {
+	io.Reader
+	n   int64
+	err *LimitError
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
+func (r *ratioReader) Read(p []byte) (int, error) {
+
+	n, err := r.Reader.Read(p)
+	r.n += int64(n)
+
+	compressed := r.compressed.n
+	if compressed < ratioFloor {
+		compressed = ratioFloor
+	}
+	if float64(r.n) > r.err.Ratio*float64(compressed) {
+		return n, r.err
+	}
+	return n, err
+}
+
+func (r *bodyLimitReader) Read(p []byte) (int, error) {
+
+	max := r.err.Max
+	if r.n > max {
+		return 0, r.err
+	}
+
+	// Read at most one byte past the limit to find out if there is more
+	if int64(len(p)) > max-r.n+1 {
+		p = p[:max-r.n+1]
+	}
+
+	n, err := r.Reader.Read(p)
+	r.n += int64(n)
+	if r.n > max {
+		return n, r.err
+	}
+	return n, err
+}
+
+// countNodes counts the nodes in the document,
+// stopping early once it has counted past max
+func countNodes(n *html.Node, max int) int {
+
+	count := 1
+	for c := n.FirstChild; c != nil && count <= max; c = c.NextSibling {
+		count += countNodes(c, max-count)
+	}
+	return count
+}
+
+func (e *LimitError) Error() string {
+	max := strconv.FormatInt(e.Max, 10)
+	if e.Limit == "MaxRatio" {
+		max = strconv.FormatFloat(e.Ratio, 'f', -1, 64)
+	}
+	return fmt.Sprintf("Page %q went over its %s limit of %s",
+		e.URL, e.Limit, max)
+}
+
+// withLimits returns a context carrying the scrape's Limits,
+// so Getters that decompress data can enforce the MaxRatio
+func withLimits(ctx context.Context, limits Limits) context.Context {
+	return context.WithValue(ctx, limitsKey{}, limits)
+}
+
+func limitsFrom(ctx context.Context) Limits {
+	limits, _ := ctx.Value(limitsKey{}).(Limits)
+	return limits
+}
+
+// limitBody stops a Getter that reads a whole body, to store
+// it, from reading more than the context's MaxBodyBytes
+func limitBody(ctx context.Context, url string, r io.Reader) io.Reader {
+	max := limitsFrom(ctx).MaxBodyBytes
+	if max <= 0 {
+		return r
+	}
+	return &bodyLimitReader{r, 0, &LimitError{url, "MaxBodyBytes", max, 0}}
+}
+
+// isLimitError reports whether the error
+// was caused by a page going over a limit
+func isLimitError(err error) bool {
+	var limitErr *LimitError
+	return errors.As(err, &limitErr)
+}
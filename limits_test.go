@@ -0,0 +1,131 @@
+package scraper
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type LimitTest struct {
+	Limits Limits
+	Path   string
+	Limit  string
+}
+
+var limitTests = []LimitTest{
+	LimitTest{Limits: Limits{MaxBodyBytes: 1000}, Path: "/big", Limit: "MaxBodyBytes"},
+	LimitTest{Limits: Limits{MaxBodyBytes: 1000}, Path: "/bomb", Limit: "MaxBodyBytes"},
+	LimitTest{Limits: Limits{MaxNodes: 100}, Path: "/nodes", Limit: "MaxNodes"},
+	LimitTest{Limits: Limits{MaxRatio: 10}, Path: "/bomb", Limit: "MaxRatio"},
+	LimitTest{Limits: Limits{MaxRatio: 10}, Path: "/small", Limit: ""},
+	LimitTest{Limits: Limits{MaxBodyBytes: 1000, MaxNodes: 100}, Path: "/small", Limit: ""},
+}
+
+var limitTestRoutes = testRoutes{
+	"/": func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `<a href="/big">big</a>`)
+	},
+	"/big": func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "<p>"+strings.Repeat("x", 2000)+"</p>")
+	},
+	"/nodes": func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, strings.Repeat("<p>x</p>", 200))
+	},
+	"/small": func(w http.ResponseWriter, r *http.Request) {
+		writeGzip(w, "<p>"+strings.Repeat("x", 100)+"</p>")
+	},
+	"/bomb": func(w http.ResponseWriter, r *http.Request) {
+		writeGzip(w, strings.Repeat(" ", 1<<20))
+	},
+}
+
+func writeGzip(w http.ResponseWriter, data string) {
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write([]byte(data))
+	zw.Close()
+
+	w.Header().Set("Content-Encoding", "gzip")
+	w.Write(buf.Bytes())
+}
+
+func TestLimits(t *testing.T) {
+
+	server := newTestServer(limitTestRoutes)
+	defer server.Close()
+
+	for _, test := range limitTests {
+
+		url := server.URL + test.Path
+		_, err := NewWithOptions(context.Background(), url, Options{
+			Limits: test.Limits,
+		}).Done()
+
+		if test.Limit == "" {
+			if err != nil {
+				t.Errorf("%+v: %v", test, err)
+			}
+			continue
+		}
+
+		var limitErr *LimitError
+		if !errors.As(err, &limitErr) {
+			t.Errorf("%+v: Expected a LimitError, received %v", test, err)
+			continue
+		}
+		if limitErr.URL != url || limitErr.Limit != test.Limit {
+			t.Errorf("%+v: Unexpected error %v", test, limitErr)
+		}
+	}
+}
+
+func TestLimits_Follow(t *testing.T) {
+
+	server := newTestServer(limitTestRoutes)
+	defer server.Close()
+
+	_, err := NewWithOptions(context.Background(), server.URL, Options{
+		Limits: Limits{MaxBodyBytes: 1000},
+	}).Follow("a[href]").Done()
+
+	var limitErr *LimitError
+	if !errors.As(err, &limitErr) || limitErr.URL != server.URL+"/big" {
+		t.Fatalf("Expected a LimitError for the followed page, received %v", err)
+	}
+}
+
+func TestLimits_StoringGetters(t *testing.T) {
+
+	server := newTestServer(limitTestRoutes)
+	defer server.Close()
+
+	dir := t.TempDir()
+	getters := []Getter{
+		&CachingGetter{Getter: HTTPGetter(), Dir: filepath.Join(dir, "cache")},
+		&RecordingGetter{Getter: HTTPGetter(), Path: filepath.Join(dir, "pages.har")},
+		&ArchivingGetter{Getter: HTTPGetter(), Dir: filepath.Join(dir, "warc")},
+	}
+
+	// Getters storing whole bodies stop reading at the limit
+	ctx := withLimits(context.Background(), Limits{MaxBodyBytes: 1000})
+	for _, getter := range getters {
+
+		_, err := getContext(ctx, getter, server.URL+"/big", "")
+
+		var limitErr *LimitError
+		if !errors.As(err, &limitErr) || limitErr.Limit != "MaxBodyBytes" {
+			t.Errorf("%T: Expected a LimitError, received %v", getter, err)
+			continue
+		}
+		if !strings.HasSuffix(err.Error(), "limit of 1000") {
+			t.Errorf("%T: Unexpected error %v", getter, err)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package scraper
 
 import (
+	"context"
 	"io"
 
 	css "github.com/andybalholm/cascadia"
@@ -37,9 +38,20 @@ type nFactoryLog struct {
 func (l nilLogger) Printf(format string, v ...interface{}) {
 }
 
+func (s *scraperLog) init(url string) Scraper {
+	s.scraper.init(url)
+	return s
+}
+
 func (s *scraperLog) Filter(selector string) Scraper {
-	s.Printf("Filtering %v nodes by %s\n", len(s.Nodes), selector)
+	steps := len(s.steps)
 	s.scraper.Filter(selector)
+	if len(s.steps) > steps {
+		// Steps run later, once the nodes are known
+		s.steps[steps].started = func(nodes int) {
+			s.Printf("Filtering %v nodes by %s\n", nodes, selector)
+		}
+	}
 	return s
 }
 
@@ -54,8 +66,12 @@ func (s *scraperLog) Done() ([]map[string]string, error) {
 }
 
 func (g *getterLog) Get(url string, srcURL string) (io.ReadCloser, error) {
+	return g.GetContext(context.Background(), url, srcURL)
+}
+
+func (g *getterLog) GetContext(ctx context.Context, url string, srcURL string) (io.ReadCloser, error) {
 	g.Printf("Getting url %q with src url %q\n", url, srcURL)
-	r, err := g.Getter.Get(url, srcURL)
+	r, err := getContext(ctx, g.Getter, url, srcURL)
 	if err != nil {
 		g.Printf("Error getting url %q: %q\n", url, err)
 	}
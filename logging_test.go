@@ -0,0 +1,67 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestScraperLog_Filter(t *testing.T) {
+
+	var logs []string
+	logger := loggerFunc(func(format string, v ...interface{}) {
+		logs = append(logs, fmt.Sprintf(format, v...))
+	})
+
+	getter := MemoryGetter{"url": "<div><p>1</p></div><div><p>2</p></div>"}
+	_, err := New("url", logger, getter).Filter("div").Filter("p").Done()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Filters log how many nodes they ran on
+	expected := []string{"Filtering 1 nodes by div\n", "Filtering 2 nodes by p\n"}
+	if filters := filterLogs(logs); !reflect.DeepEqual(filters, expected) {
+		t.Fatalf("Expected %q, received %q", expected, filters)
+	}
+}
+
+func TestScraperLog_Stream(t *testing.T) {
+
+	var logs []string
+	logger := loggerFunc(func(format string, v ...interface{}) {
+		logs = append(logs, fmt.Sprintf(format, v...))
+	})
+
+	getter := MemoryGetter{"url": "<div><p>1</p></div><div><p>2</p></div>"}
+	records, errs := New("url", logger, getter).
+		Filter("div").Filter("p").Select(Sel{"value": "p"}).
+		Stream(context.Background())
+	for range records {
+	}
+	if err := <-errs; err != nil {
+		t.Fatal(err)
+	}
+
+	// Streamed nodes reach each filter one at a time
+	expected := []string{
+		"Filtering 1 nodes by div\n",
+		"Filtering 1 nodes by p\n",
+		"Filtering 1 nodes by p\n",
+	}
+	if filters := filterLogs(logs); !reflect.DeepEqual(filters, expected) {
+		t.Fatalf("Expected %q, received %q", expected, filters)
+	}
+}
+
+func filterLogs(logs []string) []string {
+	var filters []string
+	for _, log := range logs {
+		if strings.HasPrefix(log, "Filtering") {
+			filters = append(filters, log)
+		}
+	}
+	return filters
+}
@@ -1,9 +1,12 @@
 package scraper
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"strings"
+	"sync"
 
 	css "github.com/andybalholm/cascadia"
 	"golang.org/x/net/html"
@@ -15,31 +18,50 @@ type nodeFactory interface {
 
 type node interface {
 	Filter(sel string, cssSel css.Selector) []node
-	Follow(sel string, cssSel css.Selector) []node
+	Follow(sel string, cssSel css.Selector) ([]node, error)
+	Submit(sel string, cssSel css.Selector, fields map[string]string) ([]node, error)
+	Paginate(sel string, cssSel css.Selector, opts PageOptions) ([]node, error)
+	Crawl(c *crawler) ([]node, error)
 	Select(name string, sel string, cssSel css.Selector) error
 	GetData() []map[string]string
+	// OwnData returns the data selected from this node
+	// alone, without that of the nodes it led to
+	OwnData() []map[string]string
+	// DropNodes forgets the nodes this node led to
+	DropNodes()
 }
 
 type result struct {
-	Getter
+	*nFactory
 	URL     string
 	Element *html.Node
 	Data    []map[string]string
 	Nodes   []*result
 }
 
+// nFactory holds what every page of a scrape
+// needs to get and parse the pages it links to
 type nFactory struct {
+	Context context.Context
 	Getter
+	Limits    Limits
+	documents *documentCache
+	pool      *followPool
+	// canonicalizer keys the pages of the
+	// scrape so each is only fetched once
+	canonicalizer *Canonicalizer
+	// urlKey is the key records hold their page's url under
+	urlKey string
 }
 
-func (n nFactory) Create(url string, r io.Reader) (node, error) {
+func (n *nFactory) Create(url string, r io.Reader) (node, error) {
 
-	el, err := html.Parse(r)
+	el, err := n.parse(url, r)
 	if err != nil {
 		return nil, err
 	}
 
-	return &result{n.Getter, url, el, nil, nil}, nil
+	return &result{n, url, el, nil, nil}, nil
 }
 
 func (r *result) Filter(sel string, cssSel css.Selector) []node {
@@ -54,7 +76,7 @@ func (r *result) Filter(sel string, cssSel css.Selector) []node {
 	for _, el := range elements {
 
 		node := &result{
-			r.Getter, r.URL, el, r.Data, nil,
+			r.nFactory, r.URL, el, r.Data, nil,
 		}
 
 		r.Nodes = append(r.Nodes, node)
@@ -82,35 +104,78 @@ func (r *result) Select(name string, sel string, cssSel css.Selector) error {
 		r.Data[i] = dataMap
 	}
 
+	r.addURL(r.Data)
 	return nil
 }
 
-func (r *result) Follow(sel string, cssSel css.Selector) []node {
+// addURL sets the canonical url of the page
+// on the records when the scrape has a urlKey
+func (r *result) addURL(records []map[string]string) {
 
-	if cssSel == nil {
-		return []node{}
+	if r.urlKey == "" || len(records) == 0 {
+		return
 	}
 
-	urlNodes := cssSel.MatchAll(r.Element)
-	nodes := make([]node, 0, len(urlNodes))
+	// The element may be part of the page, while
+	// its <link rel="canonical"> is in the <head>
+	root := r.Element
+	for root.Parent != nil {
+		root = root.Parent
+	}
+
+	url := r.canonicalizer.pageKey(root, r.URL)
+	for _, record := range records {
+		record[r.urlKey] = url
+	}
+}
 
-	for _, urlNode := range urlNodes {
+func (r *result) Follow(sel string, cssSel css.Selector) ([]node, error) {
+
+	if cssSel == nil {
+		return []node{}, nil
+	}
+
+	var urls []string
+	for _, urlNode := range cssSel.MatchAll(r.Element) {
 
 		url, err := textOrAttr(sel, urlNode)
 		if err != nil {
 			continue
 		}
 
-		el, err := r.followURL(url)
+		// Keep the absolute url so pages followed
+		// from this one resolve against it
+		url, err = resolveURL(url, r.URL)
 		if err != nil {
 			continue
 		}
-		nodes = append(nodes, &result{
-			r.Getter, url, el, r.Data, nil,
-		})
+		urls = append(urls, url)
 	}
 
-	return nodes
+	els, errs := r.followURLs(urls)
+	nodes := make([]node, 0, len(els))
+
+	for i, el := range els {
+
+		if err := errs[i]; err != nil {
+			if ctxErr := r.Context.Err(); ctxErr != nil {
+				return nil, ctxErr
+			}
+			if isFatal(err) {
+				return nil, err
+			}
+			continue
+		}
+
+		node := &result{
+			r.nFactory, urls[i], el, r.Data, nil,
+		}
+
+		r.Nodes = append(r.Nodes, node)
+		nodes = append(nodes, node)
+	}
+
+	return nodes, nil
 }
 
 func (r *result) GetData() []map[string]string {
@@ -129,6 +194,14 @@ func (r *result) GetData() []map[string]string {
 	return allData
 }
 
+func (r *result) OwnData() []map[string]string {
+	return r.Data
+}
+
+func (r *result) DropNodes() {
+	r.Nodes = nil
+}
+
 func selectText(selStr string, sel css.Selector, el *html.Node) (string, error) {
 
 	txt, nodes := "", sel.MatchAll(el)
@@ -144,19 +217,81 @@ func selectText(selStr string, sel css.Selector, el *html.Node) (string, error)
 	return txt, nil
 }
 
-func (r *result) followURL(url string) (*html.Node, error) {
-
-	rc, err := r.Get(url, r.URL)
-	if err != nil {
-		return nil, err
+// followURLs gets and parses the pages, through the scrape's
+// pool of workers when it has more than one, returning them
+// in the order of the urls. Pages fetched one at a time stop
+// at the first error that ends the scrape, while concurrent
+// fetches are cancelled by it.
+func (r *result) followURLs(urls []string) ([]*html.Node, []error) {
+
+	els, errs := make([]*html.Node, len(urls)), make([]error, len(urls))
+
+	if !r.pool.concurrent() {
+		for i, url := range urls {
+			els[i], errs[i] = r.followURL(r.Context, url)
+			if errs[i] != nil && (r.Context.Err() != nil || isFatal(errs[i])) {
+				return els[:i+1], errs[:i+1]
+			}
+		}
+		return els, errs
 	}
-	defer rc.Close()
-	el, err := html.Parse(rc)
-	if err != nil {
-		return nil, err
+
+	ctx, cancel := context.WithCancel(r.Context)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for i, url := range urls {
+		wg.Add(1)
+		go func(i int, url string) {
+			defer wg.Done()
+			els[i], errs[i] = r.followURL(ctx, url)
+			if errs[i] != nil && isFatal(errs[i]) {
+				cancel()
+			}
+		}(i, url)
 	}
+	wg.Wait()
+
+	return els, errs
+}
+
+func (r *result) followURL(ctx context.Context, url string) (*html.Node, error) {
+
+	key := r.canonicalizer.key(url)
+	return r.documents.load(ctx, key, func() (*html.Node, error) {
 
-	return el, nil
+		host, err := hostOf(url, "")
+		if err != nil {
+			return nil, err
+		}
+		release, err := r.pool.acquire(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+
+		rc, err := getContext(ctx, r.Getter, url, r.URL)
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		return r.parse(url, rc)
+	})
+}
+
+// isFatal reports whether an error getting a followed
+// page should stop the scrape rather than skip the page
+func isFatal(err error) bool {
+
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Action == StatusFail
+	}
+	var guardErr *GuardError
+	if errors.As(err, &guardErr) {
+		return true
+	}
+	return isLimitError(err)
 }
 
 func textOrAttr(sel string, node *html.Node) (string, error) {
@@ -0,0 +1,173 @@
+package scraper
+
+import (
+	"crypto/sha256"
+	"net/url"
+	"strconv"
+	"strings"
+
+	css "github.com/andybalholm/cascadia"
+	"golang.org/x/net/html"
+)
+
+// PageOptions configures how Paginate finds the pages
+type PageOptions struct {
+	// MaxPages limits how many pages are scraped, counting
+	// the one paginated from, zero for no limit
+	MaxPages int
+	// Template, when set, numbers the pages instead of following
+	// next links, replacing {page} in it with each page number,
+	// and resolving it against the url paginated from. A template
+	// of just a query, such as "?page={page}", sets its parameters
+	// in that url's query, keeping the others. Pages continue
+	// until one can't be got, until a page has nothing matching
+	// the next selector if one was given, or until a page is the
+	// same as the one before it, as many sites serve an empty
+	// listing for any page number.
+	Template string
+	// Start is the number of the page after the
+	// one paginated from, defaulting to 2
+	Start int
+}
+
+func (s *scraper) Paginate(nextSelector string, opts PageOptions) Scraper {
+
+	if s.Error != nil {
+		return s
+	}
+
+	var sel css.Selector
+	if nextSelector != "" {
+		var err error
+		if sel, err = css.Compile(nextSelector); err != nil {
+			return s.setError(err)
+		}
+	}
+
+	return s.addStep(step{true, true, func(n node) ([]node, error) {
+		return n.Paginate(nextSelector, sel, opts)
+	}, nil})
+}
+
+// Paginate leads to this page and every page after it, found
+// by following the next link on each page (or numbering them
+// with the Template), stopping at a url already scraped or a
+// page repeating the one before it
+func (r *result) Paginate(sel string, cssSel css.Selector, opts PageOptions) ([]node, error) {
+
+	page := &result{r.nFactory, r.URL, r.Element, r.Data, nil}
+	r.Nodes = append(r.Nodes, page)
+	nodes := []node{page}
+
+	visited := map[string]bool{r.canonicalizer.key(r.URL): true}
+	last := contentHash(r.Element)
+	number := opts.Start
+	if number == 0 {
+		number = 2
+	}
+
+	for opts.MaxPages <= 0 || len(nodes) < opts.MaxPages {
+
+		url, ok := page.nextPage(sel, cssSel, opts.Template, number, r.URL)
+		key := r.canonicalizer.key(url)
+		if !ok || visited[key] {
+			break
+		}
+		visited[key] = true
+		number++
+
+		el, err := r.followURL(r.Context, url)
+		if err != nil {
+			if ctxErr := r.Context.Err(); ctxErr != nil {
+				return nil, ctxErr
+			}
+			if isFatal(err) {
+				return nil, err
+			}
+			break
+		}
+
+		hash := contentHash(el)
+		if hash == last {
+			break
+		}
+		last = hash
+
+		page = &result{r.nFactory, url, el, r.Data, nil}
+		r.Nodes = append(r.Nodes, page)
+		nodes = append(nodes, page)
+	}
+
+	return nodes, nil
+}
+
+// nextPage returns the absolute url of the page after this
+// one, reporting false when this is the last page. Next links
+// resolve against this page and the template against start.
+func (r *result) nextPage(sel string, cssSel css.Selector, template string, number int, start string) (string, bool) {
+
+	var next *html.Node
+	if cssSel != nil {
+		next = cssSel.MatchFirst(r.Element)
+	}
+
+	var url string
+	base := r.URL
+	if template != "" {
+		if cssSel != nil && next == nil {
+			return "", false
+		}
+		url = strings.Replace(template, "{page}", strconv.Itoa(number), -1)
+		if strings.HasPrefix(url, "?") {
+			return mergeQuery(start, url)
+		}
+		base = start
+	} else {
+		if next == nil {
+			return "", false
+		}
+		var err error
+		if url, err = textOrAttr(sel, next); err != nil || url == "" {
+			return "", false
+		}
+	}
+
+	url, err := resolveURL(url, base)
+	if err != nil {
+		return "", false
+	}
+	return url, true
+}
+
+// mergeQuery sets the parameters of the query
+// in the url's own query, keeping its others
+func mergeQuery(urlStr string, query string) (string, bool) {
+
+	uri, err := url.Parse(urlStr)
+	if err != nil {
+		return "", false
+	}
+	params, err := url.ParseQuery(strings.TrimPrefix(query, "?"))
+	if err != nil {
+		return "", false
+	}
+
+	merged := uri.Query()
+	for name, values := range params {
+		merged[name] = values
+	}
+	uri.RawQuery = merged.Encode()
+	return uri.String(), true
+}
+
+// contentHash returns a hash of the rendered page,
+// to tell when a page repeats the one before it
+func contentHash(el *html.Node) [sha256.Size]byte {
+
+	h := sha256.New()
+	html.Render(h, el)
+
+	var sum [sha256.Size]byte
+	copy(sum[:], h.Sum(nil))
+	return sum
+}
@@ -0,0 +1,132 @@
+package scraper
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+type PaginateTest struct {
+	Next  string
+	Opts  PageOptions
+	Pages []int
+}
+
+var paginateTests = []PaginateTest{
+	PaginateTest{"a.next[href]", PageOptions{}, []int{1, 2, 3}},
+	PaginateTest{"a.next[href]", PageOptions{MaxPages: 2}, []int{1, 2}},
+	PaginateTest{"a.missing[href]", PageOptions{}, []int{1}},
+	PaginateTest{"", PageOptions{Template: "?page={page}"}, []int{1, 2, 3, 4, 5}},
+	PaginateTest{"a.next", PageOptions{Template: "?page={page}"}, []int{1, 2, 3, 4}},
+	PaginateTest{"", PageOptions{Template: "/list?page={page}", MaxPages: 3}, []int{1, 2, 3}},
+	PaginateTest{"", PageOptions{Template: "?page={page}", Start: 4}, []int{1, 4, 5}},
+}
+
+var paginateTestRoutes = testRoutes{
+	"/list": func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page < 1 || page > 5 {
+			http.NotFound(w, r)
+			return
+		}
+
+		fmt.Fprintf(w, `<p class="item">%d-a</p><p class="item">%d-b</p>`, page, page)
+		switch page {
+		case 1, 2:
+			fmt.Fprintf(w, `<a class="next" href="?page=%d">Next</a>`, page+1)
+		case 3:
+			// Loops back to the start
+			io.WriteString(w, `<a class="next" href="/list?page=1">Next</a>`)
+		}
+	},
+}
+
+func TestPaginate(t *testing.T) {
+
+	server := newTestServer(paginateTestRoutes)
+	defer server.Close()
+
+	for _, test := range paginateTests {
+
+		results, err := New(server.URL+"/list?page=1", nil, HTTPGetter()).
+			Paginate(test.Next, test.Opts).
+			Select(Sel{"value": ".item"}).
+			Done()
+		if err != nil {
+			t.Errorf("%+v: %v", test, err)
+			continue
+		}
+
+		var expected []map[string]string
+		for _, page := range test.Pages {
+			expected = append(expected,
+				map[string]string{"value": fmt.Sprintf("%d-a", page)},
+				map[string]string{"value": fmt.Sprintf("%d-b", page)})
+		}
+		if !reflect.DeepEqual(results, expected) {
+			t.Errorf("%+v: Expected %v, received %v", test, expected, results)
+		}
+	}
+}
+
+func TestPaginate_PathTemplate(t *testing.T) {
+
+	getter := MemoryGetter{
+		"http://example.com/list/":       `<p>1</p>`,
+		"http://example.com/list/page/2": `<p>2</p>`,
+		"http://example.com/list/page/3": `<p>3</p>`,
+	}
+
+	results, err := New("http://example.com/list/", nil, getter).
+		Paginate("", PageOptions{Template: "page/{page}"}).
+		Select(Sel{"value": "p"}).
+		Done()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []map[string]string{{"value": "1"}, {"value": "2"}, {"value": "3"}}
+	if !reflect.DeepEqual(results, expected) {
+		t.Fatalf("Expected %v, received %v", expected, results)
+	}
+}
+
+func TestPaginate_EmptyPages(t *testing.T) {
+
+	requests := 0
+	server := newTestServer(testRoutes{
+		"/list": func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			if r.URL.Query().Get("cat") != "5" {
+				http.NotFound(w, r)
+				return
+			}
+			// Every page number past the last is an empty listing
+			page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+			io.WriteString(w, `<h1>Listing</h1>`)
+			if page <= 3 {
+				fmt.Fprintf(w, `<p class="item">%d</p>`, page)
+			}
+		},
+	})
+	defer server.Close()
+
+	results, err := New(server.URL+"/list?cat=5&page=1", nil, HTTPGetter()).
+		Paginate("", PageOptions{Template: "?page={page}"}).
+		Select(Sel{"value": ".item"}).
+		Done()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []map[string]string{{"value": "1"}, {"value": "2"}, {"value": "3"}}
+	if !reflect.DeepEqual(results, expected) {
+		t.Fatalf("Expected %v, received %v", expected, results)
+	}
+	if requests != 5 {
+		t.Fatalf("Expected 5 requests, received %v", requests)
+	}
+}
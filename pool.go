@@ -0,0 +1,86 @@
+package scraper
+
+import (
+	"context"
+	"sync"
+)
+
+// followPool limits how many pages a scrape's Follows
+// fetch at once, in total and from any one host
+type followPool struct {
+	workers chan struct{}
+	perHost int
+	mu      sync.Mutex
+	hosts   map[string]chan struct{}
+}
+
+// lockedLogger serializes the calls to a Logger,
+// as pages followed at once log concurrently
+type lockedLogger struct {
+	mu sync.Mutex
+	Logger
+}
+
+func newFollowPool(workers int, perHost int) *followPool {
+
+	if workers < 1 {
+		workers = 1
+	}
+	return &followPool{
+		workers: make(chan struct{}, workers),
+		perHost: perHost,
+		hosts:   make(map[string]chan struct{}),
+	}
+}
+
+// concurrent reports whether pages are fetched more than
+// one at a time, otherwise they are fetched in order
+func (p *followPool) concurrent() bool {
+	return cap(p.workers) > 1
+}
+
+// acquire waits for a free worker, and for the host to be under
+// its limit, returning the func that hands both back once done
+func (p *followPool) acquire(ctx context.Context, host string) (func(), error) {
+
+	var hostSlots chan struct{}
+	if p.perHost > 0 {
+		p.mu.Lock()
+		hostSlots = p.hosts[host]
+		if hostSlots == nil {
+			hostSlots = make(chan struct{}, p.perHost)
+			p.hosts[host] = hostSlots
+		}
+		p.mu.Unlock()
+
+		// The host's slot is taken first so workers are not
+		// held while waiting on a busy host
+		select {
+		case hostSlots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	select {
+	case p.workers <- struct{}{}:
+	case <-ctx.Done():
+		if hostSlots != nil {
+			<-hostSlots
+		}
+		return nil, ctx.Err()
+	}
+
+	return func() {
+		<-p.workers
+		if hostSlots != nil {
+			<-hostSlots
+		}
+	}, nil
+}
+
+func (l *lockedLogger) Printf(format string, v ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.Logger.Printf(format, v...)
+}
@@ -0,0 +1,163 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// concurrencyServer serves numbered pages,
+// recording how many it served at once
+type concurrencyServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	inFlight int
+	max      int
+}
+
+func newConcurrencyServer(links string) *concurrencyServer {
+
+	s := &concurrencyServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/" {
+				io.WriteString(w, links)
+				return
+			}
+
+			s.mu.Lock()
+			s.inFlight++
+			if s.inFlight > s.max {
+				s.max = s.inFlight
+			}
+			s.mu.Unlock()
+
+			time.Sleep(20 * time.Millisecond)
+			io.WriteString(w, "<p>"+strings.TrimPrefix(r.URL.Path, "/")+"</p>")
+
+			s.mu.Lock()
+			s.inFlight--
+			s.mu.Unlock()
+		}))
+	return s
+}
+
+func pageLinks(baseURL string, n int) string {
+	links := ""
+	for i := 0; i < n; i++ {
+		links += fmt.Sprintf(`<div><a href="%s/%d">%d</a></div>`, baseURL, i, i)
+	}
+	return links
+}
+
+func verifyPageOrder(t *testing.T, results []map[string]string, n int) {
+
+	if len(results) != n {
+		t.Fatalf("Expected %v results, received %v", n, len(results))
+	}
+	for i, result := range results {
+		if result["value"] != strconv.Itoa(i) {
+			t.Fatalf("Expected results in document order, received %v", results)
+		}
+	}
+}
+
+func TestFollow_Workers(t *testing.T) {
+
+	server := newConcurrencyServer(pageLinks("", 20))
+	defer server.Close()
+
+	var log []string
+	logger := loggerFunc(func(format string, v ...interface{}) {
+		// Not safe for concurrent use by itself
+		log = append(log, fmt.Sprintf(format, v...))
+	})
+
+	results, err := NewWithOptions(context.Background(), server.URL, Options{
+		Workers: 4,
+		Logger:  logger,
+	}).
+		Follow("a[href]").
+		Select(Sel{"value": "p"}).
+		Done()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	verifyPageOrder(t, results, 20)
+	if server.max < 2 || server.max > 4 {
+		t.Errorf("Expected 2 to 4 pages at once, received %v", server.max)
+	}
+	if len(log) == 0 {
+		t.Errorf("Expected the scrape to be logged")
+	}
+}
+
+func TestFollow_WorkersFromNodes(t *testing.T) {
+
+	server := newConcurrencyServer(pageLinks("", 10))
+	defer server.Close()
+
+	results, err := NewWithOptions(context.Background(), server.URL, Options{
+		Workers: 5,
+	}).
+		Filter("div").
+		Follow("a[href]").
+		Select(Sel{"value": "p"}).
+		Done()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	verifyPageOrder(t, results, 10)
+	if server.max < 2 || server.max > 5 {
+		t.Errorf("Expected 2 to 5 pages at once, received %v", server.max)
+	}
+}
+
+func TestFollow_PerHost(t *testing.T) {
+
+	first := newConcurrencyServer("")
+	defer first.Close()
+	second := newConcurrencyServer("")
+	defer second.Close()
+
+	links := pageLinks(first.URL, 5) + pageLinks(second.URL, 5)
+	root := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, links)
+		}))
+	defer root.Close()
+
+	results, err := NewWithOptions(context.Background(), root.URL, Options{
+		Workers: 8,
+		PerHost: 1,
+	}).
+		Follow("a[href]").
+		Select(Sel{"value": "p"}).
+		Done()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(results) != 10 {
+		t.Fatalf("Expected 10 results, received %v", results)
+	}
+	if first.max != 1 || second.max != 1 {
+		t.Errorf("Expected 1 page at once per host, received %v and %v",
+			first.max, second.max)
+	}
+}
+
+type loggerFunc func(format string, v ...interface{})
+
+func (f loggerFunc) Printf(format string, v ...interface{}) {
+	f(format, v...)
+}
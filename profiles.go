@@ -0,0 +1,103 @@
+package scraper
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"math/rand"
+	"strings"
+	"sync"
+)
+
+// Profile is a coherent set of headers sent by one
+// browser, such as its User-Agent, Accept and
+// Accept-Language, so requests look consistent
+type Profile struct {
+	Name   string            `json:"name"`
+	Header map[string]string `json:"headers"`
+}
+
+// Rotation decides which Profile
+// is used for each request
+type Rotation int
+
+const (
+	// RoundRobin uses each profile in turn
+	RoundRobin Rotation = iota
+	// Random picks a profile at random for every request
+	Random
+	// StickyHost picks a profile in turn the first time a host
+	// is requested and uses it for every later request to it
+	StickyHost
+)
+
+// Profiles rotates through a set of browser
+// profiles and is safe for concurrent use
+type Profiles struct {
+	profiles []Profile
+	rotation Rotation
+	mu       sync.Mutex
+	index    int
+	hosts    map[string]int
+}
+
+// NewProfiles creates a rotation through the given profiles
+func NewProfiles(rotation Rotation, profiles ...Profile) (*Profiles, error) {
+
+	if len(profiles) == 0 {
+		return nil, errors.New("No profiles provided")
+	}
+
+	return &Profiles{
+		profiles: append([]Profile(nil), profiles...),
+		rotation: rotation,
+		hosts:    make(map[string]int),
+	}, nil
+}
+
+// LoadProfiles creates a rotation through the profiles in the
+// JSON file at path, a list of {"name": ..., "headers": {...}}
+func LoadProfiles(path string, rotation Rotation) (*Profiles, error) {
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var profiles []Profile
+	if err := json.Unmarshal(data, &profiles); err != nil {
+		return nil, err
+	}
+
+	return NewProfiles(rotation, profiles...)
+}
+
+// HTTPProfiles sends each request with the headers of
+// the next profile, overriding the Go client's defaults
+func HTTPProfiles(profiles *Profiles) HTTPOption {
+	return func(cfg *httpConfig) {
+		cfg.profiles = profiles
+	}
+}
+
+// Next returns the profile to use for a request to the host
+func (p *Profiles) Next(host string) Profile {
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	switch p.rotation {
+	case Random:
+		return p.profiles[rand.Intn(len(p.profiles))]
+	case StickyHost:
+		host = strings.ToLower(host)
+		if i, ok := p.hosts[host]; ok {
+			return p.profiles[i]
+		}
+		p.hosts[host] = p.index
+	}
+
+	profile := p.profiles[p.index]
+	p.index = (p.index + 1) % len(p.profiles)
+	return profile
+}
@@ -0,0 +1,93 @@
+package scraper
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func newTestProfiles(t *testing.T, rotation Rotation) *Profiles {
+
+	profiles, err := NewProfiles(rotation,
+		Profile{Name: "p1", Header: map[string]string{"User-Agent": "userAgent1"}},
+		Profile{Name: "p2", Header: map[string]string{"User-Agent": "userAgent2"}},
+		Profile{Name: "p3", Header: map[string]string{"User-Agent": "userAgent3"}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return profiles
+}
+
+func TestProfiles_RoundRobin(t *testing.T) {
+
+	profiles := newTestProfiles(t, RoundRobin)
+	names := []string{"p1", "p2", "p3"}
+
+	for i := 0; i < len(names)*2; i++ {
+
+		expected := names[i%len(names)]
+		actual := profiles.Next("localhost").Name
+		if actual != expected {
+			t.Errorf("Expected %s, received %s", expected, actual)
+		}
+	}
+}
+
+func TestProfiles_StickyHost(t *testing.T) {
+
+	profiles := newTestProfiles(t, StickyHost)
+
+	a, b := profiles.Next("a.com").Name, profiles.Next("b.com").Name
+	if a == b {
+		t.Fatalf("Expected different hosts to get different profiles")
+	}
+
+	for i := 0; i < 5; i++ {
+		if act := profiles.Next("A.com").Name; act != a {
+			t.Fatalf("Expected %s, received %s", a, act)
+		}
+	}
+}
+
+func TestProfiles_Concurrent(t *testing.T) {
+
+	for _, rotation := range []Rotation{RoundRobin, Random, StickyHost} {
+
+		profiles := newTestProfiles(t, rotation)
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if profiles.Next("localhost").Name == "" {
+					t.Error("Expected a profile")
+				}
+			}()
+		}
+		wg.Wait()
+	}
+}
+
+func TestProfiles_HTTPGetter(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, r.Header.Get("Accept-Language")+"|"+r.UserAgent())
+		}))
+	defer server.Close()
+
+	profiles, err := LoadProfiles("./testFiles/profiles.json", RoundRobin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	getter := HTTPGetter(HTTPProfiles(profiles))
+	verifyGetter(t, getter, server.URL, "en-US,en;q=0.5|"+
+		"Mozilla/5.0 (X11; Linux x86_64; rv:120.0) Gecko/20100101 Firefox/120.0")
+	verifyGetter(t, getter, server.URL, "en-US,en;q=0.9|"+
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 "+
+		"(KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
+}
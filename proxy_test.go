@@ -0,0 +1,145 @@
+package scraper
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newTestProxy stands in for a forwarding proxy,
+// answering every request itself with its name
+func newTestProxy(name string) *httptest.Server {
+
+	return httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, name+" "+r.URL.String())
+		}))
+}
+
+func TestProxyPool_RoundRobin(t *testing.T) {
+
+	proxy1, proxy2 := newTestProxy("proxy1"), newTestProxy("proxy2")
+	defer proxy1.Close()
+	defer proxy2.Close()
+
+	pool, err := NewProxyPool(RoundRobin, proxy1.URL, proxy2.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	getter := HTTPGetter(HTTPProxyPool(pool))
+	verifyGetter(t, getter, "http://example.com/", "proxy1 http://example.com/")
+	verifyGetter(t, getter, "http://example.com/", "proxy2 http://example.com/")
+	verifyGetter(t, getter, "http://example.com/", "proxy1 http://example.com/")
+
+	stats := pool.Stats()
+	if stats[0].Successes != 2 || stats[1].Successes != 1 {
+		t.Fatalf("Unexpected stats: %+v", stats)
+	}
+}
+
+func TestProxyPool_StickyHost(t *testing.T) {
+
+	proxy1, proxy2 := newTestProxy("proxy1"), newTestProxy("proxy2")
+	defer proxy1.Close()
+	defer proxy2.Close()
+
+	pool, err := NewProxyPool(StickyHost, proxy1.URL, proxy2.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	getter := HTTPGetter(HTTPProxyPool(pool))
+	verifyGetter(t, getter, "http://a.com/", "proxy1 http://a.com/")
+	verifyGetter(t, getter, "http://b.com/", "proxy2 http://b.com/")
+	verifyGetter(t, getter, "http://a.com/2", "proxy1 http://a.com/2")
+	verifyGetter(t, getter, "http://b.com/2", "proxy2 http://b.com/2")
+}
+
+func TestProxyPool_Health(t *testing.T) {
+
+	good, dead := newTestProxy("good"), newTestProxy("dead")
+	defer good.Close()
+	dead.Close()
+
+	pool, err := NewProxyPool(RoundRobin, dead.URL, good.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool.MaxFailures = 2
+	pool.Cooldown = 100 * time.Millisecond
+
+	getter := HTTPGetter(HTTPProxyPool(pool))
+	for i := 0; i < 4; i++ {
+		getter.Get("http://example.com/", "")
+	}
+
+	// Once benched, only the good proxy is used
+	for i := 0; i < 3; i++ {
+		verifyGetter(t, getter, "http://example.com/", "good http://example.com/")
+	}
+
+	stats := pool.Stats()
+	if stats[0].Failures != 2 || stats[0].Healthy {
+		t.Fatalf("Expected the dead proxy to be benched: %+v", stats[0])
+	}
+	if stats[1].Successes != 5 || !stats[1].Healthy {
+		t.Fatalf("Expected the good proxy to be healthy: %+v", stats[1])
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if !pool.Stats()[0].Healthy {
+		t.Fatalf("Expected the dead proxy back after its cooldown")
+	}
+}
+
+func TestProxyPool_NoneHealthy(t *testing.T) {
+
+	dead := newTestProxy("dead")
+	dead.Close()
+
+	pool, err := NewProxyPool(RoundRobin, dead.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool.MaxFailures = 1
+
+	getter := HTTPGetter(HTTPProxyPool(pool))
+	if _, err := getter.Get("http://example.com/", ""); err == nil {
+		t.Fatalf("Expected the dead proxy to fail")
+	}
+	if _, err := getter.Get("http://example.com/", ""); !errors.Is(err, ErrNoHealthyProxy) {
+		t.Fatalf("Expected %q, received %v", ErrNoHealthyProxy, err)
+	}
+}
+
+func TestProxyPool_Statuses(t *testing.T) {
+
+	status := http.StatusBadGateway
+	proxy := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+		}))
+	defer proxy.Close()
+
+	pool, err := NewProxyPool(RoundRobin, proxy.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	getter := HTTPGetter(HTTPProxyPool(pool))
+
+	// A site's own 502 passes through a healthy proxy
+	getter.Get("http://example.com/", "")
+	if stats := pool.Stats()[0]; stats.Failures != 0 {
+		t.Fatalf("Expected a 502 not to count against the proxy: %+v", stats)
+	}
+
+	status = http.StatusProxyAuthRequired
+	getter.Get("http://example.com/", "")
+	if stats := pool.Stats()[0]; stats.Failures != 1 {
+		t.Fatalf("Expected a 407 to count against the proxy: %+v", stats)
+	}
+}
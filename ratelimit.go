@@ -0,0 +1,176 @@
+package scraper
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// RateLimitedGetter is a Getter that paces the requests it
+// sends to each host. Its limits are tracked per host and
+// shared by every Scraper using the same RateLimitedGetter.
+type RateLimitedGetter struct {
+	Getter
+	// RequestsPerSecond limits how often each
+	// host is sent a request, zero for no limit
+	RequestsPerSecond float64
+	// MinDelay is the least time between the
+	// start of two requests to the same host
+	MinDelay time.Duration
+	// MaxConcurrent limits how many requests each host
+	// has in flight at once, zero for no limit. A request
+	// stays in flight until its body is closed.
+	MaxConcurrent int
+
+	pacer hostPacer
+	mu    sync.Mutex
+	slots map[string]chan struct{}
+}
+
+// hostPacer spaces out the
+// requests made to each host
+type hostPacer struct {
+	mu   sync.Mutex
+	next map[string]time.Time
+}
+
+// releaseCloser calls release once
+// its ReadCloser has been closed
+type releaseCloser struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+// Get retrieves the url once the host's limits allow it
+func (g *RateLimitedGetter) Get(url string, srcURL string) (io.ReadCloser, error) {
+	return g.GetContext(context.Background(), url, srcURL)
+}
+
+// GetContext retrieves the url once the host's
+// limits allow it or fails when the context is done
+func (g *RateLimitedGetter) GetContext(ctx context.Context, url string, srcURL string) (io.ReadCloser, error) {
+
+	host, err := hostOf(url, srcURL)
+	if err != nil {
+		return nil, err
+	}
+
+	release, err := g.acquire(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := g.pacer.wait(ctx, host, g.interval()); err != nil {
+		release()
+		return nil, err
+	}
+
+	rc, err := getContext(ctx, g.Getter, url, srcURL)
+	if err != nil {
+		release()
+		return nil, err
+	}
+
+	return &releaseCloser{ReadCloser: rc, release: release}, nil
+}
+
+func (g *RateLimitedGetter) interval() time.Duration {
+
+	interval := g.MinDelay
+	if g.RequestsPerSecond > 0 {
+		perRequest := time.Duration(float64(time.Second) / g.RequestsPerSecond)
+		if perRequest > interval {
+			interval = perRequest
+		}
+	}
+	return interval
+}
+
+// acquire takes one of the host's concurrency slots,
+// returning the func that gives the slot back
+func (g *RateLimitedGetter) acquire(ctx context.Context, host string) (func(), error) {
+
+	if g.MaxConcurrent <= 0 {
+		return func() {}, nil
+	}
+
+	g.mu.Lock()
+	if g.slots == nil {
+		g.slots = make(map[string]chan struct{})
+	}
+	slots := g.slots[host]
+	if slots == nil {
+		slots = make(chan struct{}, g.MaxConcurrent)
+		g.slots[host] = slots
+	}
+	g.mu.Unlock()
+
+	select {
+	case slots <- struct{}{}:
+		return func() { <-slots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// wait blocks until the host may be sent another request,
+// reserving the next slot interval after it for later callers
+func (p *hostPacer) wait(ctx context.Context, host string, interval time.Duration) error {
+
+	if interval <= 0 {
+		return nil
+	}
+
+	p.mu.Lock()
+	if p.next == nil {
+		p.next = make(map[string]time.Time)
+	}
+	now := time.Now()
+	start := p.next[host]
+	if start.Before(now) {
+		start = now
+	}
+	p.next[host] = start.Add(interval)
+	p.mu.Unlock()
+
+	return sleep(ctx, start.Sub(now))
+}
+
+// sleep waits for the duration or until the context is done
+func sleep(ctx context.Context, d time.Duration) error {
+
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func (r *releaseCloser) StatusCode() int {
+	if resp, ok := r.ReadCloser.(Response); ok {
+		return resp.StatusCode()
+	}
+	// Getters such as a MemoryGetter only return found pages
+	return http.StatusOK
+}
+
+func (r *releaseCloser) Header() http.Header {
+	return responseHeader(r.ReadCloser)
+}
+
+func (r *releaseCloser) Close() error {
+	err := r.ReadCloser.Close()
+	r.once.Do(r.release)
+	return err
+}
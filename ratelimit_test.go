@@ -0,0 +1,128 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRateLimitedGetter_Delay(t *testing.T) {
+
+	var mu sync.Mutex
+	times := map[string][]time.Time{}
+	getter := &RateLimitedGetter{
+		Getter: getterFunc(func(url string, srcURL string) (io.ReadCloser, error) {
+			host, _ := hostOf(url, srcURL)
+			mu.Lock()
+			times[host] = append(times[host], time.Now())
+			mu.Unlock()
+			return ioutil.NopCloser(strings.NewReader("")), nil
+		}),
+		MinDelay: 50 * time.Millisecond,
+	}
+
+	var wg sync.WaitGroup
+	for _, url := range []string{
+		"http://a/1", "http://a/2", "http://a/3",
+		"http://b/1", "/2", "/3",
+	} {
+		wg.Add(1)
+		go func(url string) {
+			defer wg.Done()
+			rc, err := getter.Get(url, "http://b/")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			rc.Close()
+		}(url)
+	}
+	wg.Wait()
+
+	for host, hostTimes := range times {
+		if len(hostTimes) != 3 {
+			t.Fatalf("Expected 3 requests to %s, received %v",
+				host, len(hostTimes))
+		}
+		first, last := hostTimes[0], hostTimes[0]
+		for _, tm := range hostTimes {
+			if tm.Before(first) {
+				first = tm
+			}
+			if tm.After(last) {
+				last = tm
+			}
+		}
+		if last.Sub(first) < 100*time.Millisecond {
+			t.Fatalf("Requests to %s were not spaced out: %v",
+				host, last.Sub(first))
+		}
+	}
+}
+
+func TestRateLimitedGetter_RequestsPerSecond(t *testing.T) {
+
+	getter := &RateLimitedGetter{
+		Getter:            MemoryGetter{"http://a/": "data"},
+		RequestsPerSecond: 20,
+	}
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		verifyGetter(t, getter, "http://a/", "data")
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Fatalf("Expected 3 requests to take at least 100ms, took %v", elapsed)
+	}
+}
+
+func TestRateLimitedGetter_MaxConcurrent(t *testing.T) {
+
+	getter := &RateLimitedGetter{
+		Getter:        MemoryGetter{"http://a/": "data", "http://b/": "data"},
+		MaxConcurrent: 1,
+	}
+
+	rc, err := getter.Get("http://a/", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Other hosts are not held up by a busy host
+	verifyGetter(t, getter, "http://b/", "data")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := getter.GetContext(ctx, "http://a/", ""); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected %q, received %v", context.DeadlineExceeded, err)
+	}
+
+	rc.Close()
+	verifyGetter(t, getter, "http://a/", "data")
+}
+
+func TestRateLimitedGetter_StatusCode(t *testing.T) {
+
+	getter := &RateLimitedGetter{
+		Getter:        MemoryGetter{"http://a/": "data"},
+		MaxConcurrent: 1,
+	}
+
+	rc, err := getter.Get("http://a/", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+
+	// Caching and recording getters store the status passed on
+	resp, ok := rc.(Response)
+	if !ok || resp.StatusCode() != http.StatusOK {
+		t.Fatalf("Expected status %v, received %v", http.StatusOK, rc)
+	}
+}
@@ -0,0 +1,127 @@
+package scraper
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// Request describes a request richer than a plain GET,
+// such as a POST with a body or one with extra headers
+type Request struct {
+	// Method defaults to GET when empty
+	Method string
+	URL    string
+	// Referer is sent as the Referer header and is the
+	// url a relative URL is resolved against
+	Referer string
+	Header  http.Header
+	Body    []byte
+	// Query values replace those of the same name in URL
+	Query url.Values
+}
+
+// RequestGetter is a Getter that can send full Requests
+type RequestGetter interface {
+	Getter
+	Do(ctx context.Context, req *Request) (io.ReadCloser, error)
+}
+
+type requestKey struct{}
+
+type startKey struct{}
+
+// Do sends the request with the given Getter. Getters that
+// are not RequestGetters are handed the request through the
+// context by GetContext, so that decorators wrapping the
+// HTTPGetter pass the whole request along unchanged.
+func Do(ctx context.Context, g Getter, req *Request) (io.ReadCloser, error) {
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if rg, ok := g.(RequestGetter); ok {
+		return rg.Do(ctx, req)
+	}
+
+	url, err := req.target()
+	if err != nil {
+		return nil, err
+	}
+
+	return getContext(withRequest(ctx, req), g, url, req.Referer)
+}
+
+// withRequest returns a context carrying a copy of the request
+func withRequest(ctx context.Context, req *Request) context.Context {
+	r := *req
+	r.Query = nil
+	return context.WithValue(ctx, requestKey{}, r)
+}
+
+// withoutRequest returns a context for the separate requests
+// a decorator makes itself, such as for robots.txt, so they
+// are sent as plain GETs rather than as the caller's request
+func withoutRequest(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(requestKey{}).(Request); !ok {
+		return ctx
+	}
+	return context.WithValue(ctx, requestKey{}, Request{})
+}
+
+// withRequestHeader returns a context asking the HTTPGetter to
+// send the extra headers, letting decorators such as the
+// CachingGetter add headers through any Getters in between
+func withRequestHeader(ctx context.Context, header http.Header) context.Context {
+
+	req := requestFrom(ctx)
+	merged := req.Header.Clone()
+	if merged == nil {
+		merged = http.Header{}
+	}
+	for name, values := range header {
+		merged[name] = values
+	}
+
+	req.Header = merged
+	return withRequest(ctx, &req)
+}
+
+// withStart returns a context carrying the url a scrape
+// started from, which credentials are scoped to
+func withStart(ctx context.Context, url string) context.Context {
+	return context.WithValue(ctx, startKey{}, url)
+}
+
+func startFrom(ctx context.Context) string {
+	start, _ := ctx.Value(startKey{}).(string)
+	return start
+}
+
+// requestFrom returns a copy of the request
+// carried by the context, if there is one
+func requestFrom(ctx context.Context) Request {
+	req, _ := ctx.Value(requestKey{}).(Request)
+	return req
+}
+
+// target returns the request's URL with its Query applied
+func (r *Request) target() (string, error) {
+
+	if len(r.Query) == 0 {
+		return r.URL, nil
+	}
+
+	uri, err := url.Parse(r.URL)
+	if err != nil {
+		return "", err
+	}
+
+	query := uri.Query()
+	for name, values := range r.Query {
+		query[name] = values
+	}
+	uri.RawQuery = query.Encode()
+	return uri.String(), nil
+}
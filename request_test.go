@@ -0,0 +1,105 @@
+package scraper
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestDo(t *testing.T) {
+
+	server := newTestServer(testRoutes{
+		"/robots.txt": func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, "User-agent: *\nAllow: /")
+		},
+		// Echoes what the request was sent with
+		"/path": func(w http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			io.WriteString(w, strings.Join([]string{
+				r.Method,
+				r.URL.RequestURI(),
+				r.Header.Get("X-Test"),
+				r.Header.Get("Referer"),
+				string(body),
+			}, "|"))
+		},
+	})
+	defer server.Close()
+
+	// Requests pass through Getters that are
+	// not RequestGetters without losing anything
+	getters := []Getter{
+		HTTPGetter(),
+		&RetryGetter{Getter: HTTPGetter()},
+		&RobotsGetter{Getter: &RetryGetter{Getter: HTTPGetter()}},
+	}
+
+	for _, getter := range getters {
+
+		rc, err := Do(context.Background(), getter, &Request{
+			Method:  http.MethodPut,
+			URL:     "/path?a=1&b=2",
+			Referer: server.URL + "/src",
+			Header:  http.Header{"X-Test": {"header"}},
+			Body:    []byte("body"),
+			Query:   url.Values{"b": {"3"}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		data, _ := ioutil.ReadAll(rc)
+		rc.Close()
+
+		exp := "PUT|/path?a=1&b=3|header|" + server.URL + "/src|body"
+		if act := string(data); act != exp {
+			t.Fatalf("%T: Expected %q, received %q", getter, exp, act)
+		}
+	}
+}
+
+func TestDo_Getter(t *testing.T) {
+
+	getter := MemoryGetter{"http://localhost/?page=2": "page 2"}
+	rc, err := Do(context.Background(), getter, &Request{
+		URL:   "http://localhost/",
+		Query: url.Values{"page": {"2"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := ioutil.ReadAll(rc)
+	if string(data) != "page 2" {
+		t.Fatalf("Expected %q, received %q", "page 2", data)
+	}
+}
+
+func TestFollow_Referer(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/" {
+				io.WriteString(w, `<a href="/next">next</a>`)
+				return
+			}
+			io.WriteString(w, "<p>"+r.Header.Get("Referer")+"</p>")
+		}))
+	defer server.Close()
+
+	results, err := New(server.URL+"/", nil, HTTPGetter()).
+		Follow("a[href]").
+		Select(Sel{"value": "p"}).
+		Done()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0]["value"] != server.URL+"/" {
+		t.Fatalf("Expected the src url as referer, received %v", results)
+	}
+}
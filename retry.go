@@ -0,0 +1,202 @@
+package scraper
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	defaultRetryAttempts  = 3
+	defaultRetryBaseDelay = 500 * time.Millisecond
+	defaultRetryMaxDelay  = 30 * time.Second
+)
+
+// RetryGetter is a Getter that retries failed requests
+// to the Getter it wraps, backing off exponentially
+// between attempts. Zero fields use sensible defaults.
+// Requests that are not idempotent, such as the POST of
+// a Submit, are only tried once unless RetryUnsafe is set.
+type RetryGetter struct {
+	Getter
+	// Attempts is the total number of tries made for each url
+	Attempts int
+	// BaseDelay is the wait before the first retry,
+	// doubling (with jitter) for each retry after it
+	BaseDelay time.Duration
+	// MaxDelay caps the wait between any two attempts,
+	// including waits asked for by a Retry-After header
+	MaxDelay time.Duration
+	// Retryable decides which errors are worth
+	// another attempt, defaulting to IsRetryable
+	Retryable func(err error) bool
+	// RetryUnsafe also retries requests with methods such
+	// as POST, which the server may have acted on already
+	RetryUnsafe bool
+}
+
+// IsRetryable reports whether an error looks transient: a
+// network timeout, a reset or refused connection, a response
+// cut short, or a 429, 502, 503 or 504 status error. Errors
+// that would fail the same way again, such as a GuardError,
+// a LimitError or a bad certificate, are never retried.
+func IsRetryable(err error) bool {
+
+	if errors.Is(err, context.Canceled) ||
+		errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var guardErr *GuardError
+	var limitErr *LimitError
+	var certErr *tls.CertificateVerificationError
+	var authorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidErr x509.CertificateInvalidError
+	if errors.As(err, &guardErr) || errors.As(err, &limitErr) ||
+		errors.As(err, &certErr) || errors.As(err, &authorityErr) ||
+		errors.As(err, &hostnameErr) || errors.As(err, &invalidErr) {
+		return false
+	}
+
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		switch statusErr.StatusCode {
+		case http.StatusTooManyRequests,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	return errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// Get retrieves the url, retrying transient failures
+func (g *RetryGetter) Get(url string, srcURL string) (io.ReadCloser, error) {
+	return g.GetContext(context.Background(), url, srcURL)
+}
+
+// GetContext retrieves the url, retrying transient failures
+// until the attempts run out or the context is done
+func (g *RetryGetter) GetContext(ctx context.Context, url string, srcURL string) (io.ReadCloser, error) {
+
+	attempts := g.Attempts
+	if attempts <= 0 {
+		attempts = defaultRetryAttempts
+	}
+	retryable := g.Retryable
+	if retryable == nil {
+		retryable = IsRetryable
+	}
+	if !g.RetryUnsafe && !idempotent(requestFrom(ctx).Method) {
+		attempts = 1
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < attempts; attempt++ {
+
+		if attempt > 0 {
+			if err := sleep(ctx, g.delay(attempt, lastErr)); err != nil {
+				return nil, err
+			}
+		}
+
+		rc, err := getContext(ctx, g.Getter, url, srcURL)
+		if err == nil {
+			return rc, nil
+		}
+		if !retryable(err) {
+			return nil, err
+		}
+		lastErr = err
+	}
+
+	return nil, lastErr
+}
+
+// idempotent reports whether sending a request with the
+// method more than once has the same effect as sending it once
+func idempotent(method string) bool {
+	switch strings.ToUpper(method) {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions,
+		http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// delay works out how long to wait before the given attempt,
+// preferring the server's Retry-After over the backoff
+func (g *RetryGetter) delay(attempt int, err error) time.Duration {
+
+	base, max := g.BaseDelay, g.MaxDelay
+	if base <= 0 {
+		base = defaultRetryBaseDelay
+	}
+	if max <= 0 {
+		max = defaultRetryMaxDelay
+	}
+
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		if wait, ok := retryAfter(statusErr.Header); ok {
+			if wait > max {
+				return max
+			}
+			return wait
+		}
+	}
+
+	backoff := base << uint(attempt-1)
+	if backoff <= 0 || backoff > max {
+		backoff = max
+	}
+
+	// Wait somewhere between half and all of the backoff so
+	// that many scrapers don't retry a server in lockstep
+	half := backoff / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// retryAfter parses a Retry-After header given
+// either in seconds or as an HTTP date
+func retryAfter(header http.Header) (time.Duration, bool) {
+
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		wait := time.Until(date)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,240 @@
+package scraper
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"syscall"
+	"testing"
+	"time"
+)
+
+type RetryTest struct {
+	Failures int
+	Status   int
+	Attempts int
+	Exp      int
+	OK       bool
+}
+
+var retryTests = []RetryTest{
+	RetryTest{Failures: 0, Status: 503, Attempts: 3, Exp: 1, OK: true},
+	RetryTest{Failures: 2, Status: 503, Attempts: 3, Exp: 3, OK: true},
+	RetryTest{Failures: 3, Status: 502, Attempts: 3, Exp: 3, OK: false},
+	RetryTest{Failures: 1, Status: 429, Attempts: 2, Exp: 2, OK: true},
+	RetryTest{Failures: 1, Status: 404, Attempts: 3, Exp: 1, OK: false},
+}
+
+func TestRetryGetter(t *testing.T) {
+	for _, test := range retryTests {
+		retryTest(t, test)
+	}
+}
+
+func retryTest(t *testing.T, test RetryTest) {
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			if requests <= test.Failures {
+				w.WriteHeader(test.Status)
+				return
+			}
+			io.WriteString(w, "done")
+		}))
+	defer server.Close()
+
+	getter := &RetryGetter{
+		Getter:    HTTPGetter(),
+		Attempts:  test.Attempts,
+		BaseDelay: time.Millisecond,
+	}
+
+	rc, err := getter.Get(server.URL, "")
+	if test.OK && err != nil {
+		t.Fatalf("%+v: %v", test, err)
+	}
+	if !test.OK && err == nil {
+		t.Fatalf("%+v: Expected an error", test)
+	}
+	if rc != nil {
+		rc.Close()
+	}
+	if requests != test.Exp {
+		t.Fatalf("%+v: Expected %v requests, received %v",
+			test, test.Exp, requests)
+	}
+}
+
+func TestRetryGetter_RetryAfter(t *testing.T) {
+
+	var times []time.Time
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			times = append(times, time.Now())
+			if len(times) == 1 {
+				w.Header().Set("Retry-After", "1")
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			io.WriteString(w, "done")
+		}))
+	defer server.Close()
+
+	getter := &RetryGetter{
+		Getter:    HTTPGetter(),
+		BaseDelay: time.Millisecond,
+	}
+	verifyGetter(t, getter, server.URL, "done")
+
+	if len(times) != 2 {
+		t.Fatalf("Expected 2 requests, received %v", len(times))
+	}
+	if wait := times[1].Sub(times[0]); wait < time.Second {
+		t.Fatalf("Expected to wait at least 1s, waited %v", wait)
+	}
+}
+
+func TestRetryGetter_Cancelled(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	getter := &RetryGetter{
+		Getter:    HTTPGetter(),
+		Attempts:  10,
+		BaseDelay: time.Second,
+	}
+
+	_, err := getter.GetContext(ctx, server.URL, "")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected %q, received %v", context.DeadlineExceeded, err)
+	}
+}
+
+func TestRetryGetter_Retryable(t *testing.T) {
+
+	calls := 0
+	errTest := errors.New("test error")
+	getter := &RetryGetter{
+		Getter: getterFunc(func(url string, srcURL string) (io.ReadCloser, error) {
+			calls++
+			return nil, errTest
+		}),
+		Attempts:  4,
+		BaseDelay: time.Millisecond,
+		Retryable: func(err error) bool { return err == errTest },
+	}
+
+	if _, err := getter.Get("url", ""); err != errTest {
+		t.Fatalf("Expected %q, received %v", errTest, err)
+	}
+	if calls != 4 {
+		t.Fatalf("Expected 4 calls, received %v", calls)
+	}
+}
+
+type getterFunc func(url string, srcURL string) (io.ReadCloser, error)
+
+func (f getterFunc) Get(url string, srcURL string) (io.ReadCloser, error) {
+	return f(url, srcURL)
+}
+
+func TestRetryGetter_Unsafe(t *testing.T) {
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+	defer server.Close()
+
+	post := &Request{Method: http.MethodPost, URL: server.URL, Body: []byte("a=1")}
+	for _, unsafe := range []bool{false, true} {
+
+		requests = 0
+		getter := &RetryGetter{
+			Getter:      HTTPGetter(),
+			Attempts:    3,
+			BaseDelay:   time.Millisecond,
+			RetryUnsafe: unsafe,
+		}
+		if _, err := Do(context.Background(), getter, post); err == nil {
+			t.Fatalf("Expected an error")
+		}
+
+		// A POST is only retried when asked for
+		expected := 1
+		if unsafe {
+			expected = 3
+		}
+		if requests != expected {
+			t.Errorf("RetryUnsafe %v: Expected %v requests, received %v",
+				unsafe, expected, requests)
+		}
+	}
+}
+
+func TestRetryGetter_Guarded(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, "done")
+		}))
+	defer server.Close()
+
+	calls := 0
+	guarded := HTTPGetter(HTTPGuard(&Guard{}))
+	getter := &RetryGetter{
+		Getter: getterFunc(func(url string, srcURL string) (io.ReadCloser, error) {
+			calls++
+			return guarded.Get(url, srcURL)
+		}),
+		Attempts:  3,
+		BaseDelay: time.Millisecond,
+	}
+
+	_, err := getter.Get(server.URL, "")
+
+	var guardErr *GuardError
+	if !errors.As(err, &guardErr) {
+		t.Fatalf("Expected a GuardError, received %v", err)
+	}
+	if calls != 1 {
+		t.Fatalf("Expected 1 call, received %v", calls)
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+
+	for _, test := range []struct {
+		Err error
+		Exp bool
+	}{
+		{&url.Error{Op: "Get", URL: "http://a", Err: syscall.ECONNRESET}, true},
+		{&url.Error{Op: "Get", URL: "http://a", Err: io.ErrUnexpectedEOF}, true},
+		{&url.Error{Op: "Get", URL: "http://a", Err: errors.New("unsupported protocol scheme")}, false},
+		{&url.Error{Op: "Get", URL: "http://a", Err: &net.DNSError{Err: "no such host", IsNotFound: true}}, false},
+		{&url.Error{Op: "Get", URL: "http://a", Err: &net.DNSError{Err: "timeout", IsTimeout: true}}, true},
+		{&url.Error{Op: "Get", URL: "http://a", Err: x509.UnknownAuthorityError{}}, false},
+		{&url.Error{Op: "Get", URL: "http://a", Err: &GuardError{"a", "blocked"}}, false},
+		{&LimitError{"a", "MaxBodyBytes", 1, 0}, false},
+	} {
+		if IsRetryable(test.Err) != test.Exp {
+			t.Errorf("Expected IsRetryable(%v) to be %v", test.Err, test.Exp)
+		}
+	}
+}
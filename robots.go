@@ -0,0 +1,322 @@
+package scraper
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RobotsGetter is a Getter that only retrieves urls the
+// site's robots.txt allows, waiting out any Crawl-delay
+// between requests. Each host's robots.txt is retrieved
+// once through the wrapped Getter and then cached.
+type RobotsGetter struct {
+	Getter
+	// UserAgent is the robot name matched against the
+	// User-agent lines, using the * group when empty
+	UserAgent string
+
+	pacer  hostPacer
+	mu     sync.Mutex
+	robots map[string]*robotsEntry
+}
+
+// RobotsError is returned when a url
+// is disallowed by the site's robots.txt
+type RobotsError struct {
+	URL string
+}
+
+type robotsEntry struct {
+	done   chan struct{}
+	robots *robotsFile
+	err    error
+}
+
+type robotsFile struct {
+	groups     []robotsGroup
+	sitemaps   []string
+	allowAll   bool
+	defaultIdx int
+}
+
+type robotsGroup struct {
+	agents     []string
+	rules      []robotsRule
+	crawlDelay time.Duration
+}
+
+type robotsRule struct {
+	allow   bool
+	length  int
+	pattern *regexp.Regexp
+}
+
+// Get retrieves the url if robots.txt allows it
+func (g *RobotsGetter) Get(url string, srcURL string) (io.ReadCloser, error) {
+	return g.GetContext(context.Background(), url, srcURL)
+}
+
+// GetContext retrieves the url if robots.txt allows it,
+// after waiting for the site's Crawl-delay to pass
+func (g *RobotsGetter) GetContext(ctx context.Context, url string, srcURL string) (io.ReadCloser, error) {
+
+	resolved, err := resolveURL(url, srcURL)
+	if err != nil {
+		return nil, err
+	}
+
+	uri, robots, err := g.lookup(ctx, resolved)
+	if err != nil {
+		return nil, err
+	}
+
+	group := robots.group(g.UserAgent)
+	if !group.allowed(uri) {
+		return nil, &RobotsError{resolved}
+	}
+
+	err = g.pacer.wait(ctx, strings.ToLower(uri.Host), group.crawlDelay)
+	if err != nil {
+		return nil, err
+	}
+
+	return getContext(ctx, g.Getter, url, srcURL)
+}
+
+// Allowed reports whether robots.txt allows the url to be retrieved
+func (g *RobotsGetter) Allowed(ctx context.Context, url string) (bool, error) {
+
+	uri, robots, err := g.lookup(ctx, url)
+	if err != nil {
+		return false, err
+	}
+	return robots.group(g.UserAgent).allowed(uri), nil
+}
+
+// Sitemaps returns the Sitemap urls listed
+// in the robots.txt of the url's site
+func (g *RobotsGetter) Sitemaps(ctx context.Context, url string) ([]string, error) {
+
+	_, robots, err := g.lookup(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	return append([]string(nil), robots.sitemaps...), nil
+}
+
+// lookup returns the parsed url along with the
+// robots.txt of its site, retrieving it if needed
+func (g *RobotsGetter) lookup(ctx context.Context, urlStr string) (*url.URL, *robotsFile, error) {
+
+	uri, err := url.Parse(urlStr)
+	if err != nil {
+		return nil, nil, err
+	}
+	if uri.Host == "" {
+		return nil, nil, fmt.Errorf("No host found in url %q", urlStr)
+	}
+
+	site := strings.ToLower(uri.Scheme + "://" + uri.Host)
+
+	g.mu.Lock()
+	if g.robots == nil {
+		g.robots = make(map[string]*robotsEntry)
+	}
+	entry, found := g.robots[site]
+	if !found {
+		entry = &robotsEntry{done: make(chan struct{})}
+		g.robots[site] = entry
+	}
+	g.mu.Unlock()
+
+	if !found {
+		entry.robots, entry.err = g.fetch(ctx, site)
+		if entry.err != nil {
+			// Forget failures so a later request tries again
+			g.mu.Lock()
+			delete(g.robots, site)
+			g.mu.Unlock()
+		}
+		close(entry.done)
+	}
+
+	select {
+	case <-entry.done:
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	}
+
+	if entry.err != nil {
+		return nil, nil, entry.err
+	}
+	return uri, entry.robots, nil
+}
+
+// fetch retrieves and parses a site's robots.txt. As in
+// RFC 9309 a site without one (a 4xx status, or a getter
+// with no data for it) allows everything, while any other
+// failure is returned.
+func (g *RobotsGetter) fetch(ctx context.Context, site string) (*robotsFile, error) {
+
+	rc, err := getContext(withoutRequest(ctx), g.Getter, site+"/robots.txt", "")
+	if err != nil {
+		var statusErr *HTTPStatusError
+		if errors.As(err, &statusErr) &&
+			statusErr.StatusCode >= 400 && statusErr.StatusCode < 500 {
+			return &robotsFile{allowAll: true}, nil
+		}
+		if errors.Is(err, fs.ErrNotExist) {
+			return &robotsFile{allowAll: true}, nil
+		}
+		return nil, err
+	}
+
+	defer rc.Close()
+	return parseRobots(rc)
+}
+
+func parseRobots(r io.Reader) (*robotsFile, error) {
+
+	robots := &robotsFile{defaultIdx: -1}
+	var group *robotsGroup
+	inAgents := false
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		i := strings.Index(line, ":")
+		if i < 0 {
+			continue
+		}
+		key := strings.ToLower(strings.TrimSpace(line[:i]))
+		value := strings.TrimSpace(line[i+1:])
+
+		switch key {
+		case "user-agent":
+			// Consecutive User-agent lines share one group
+			if !inAgents {
+				robots.groups = append(robots.groups, robotsGroup{})
+				group = &robots.groups[len(robots.groups)-1]
+			}
+			inAgents = true
+			group.agents = append(group.agents, productToken(value))
+			if value == "*" {
+				robots.defaultIdx = len(robots.groups) - 1
+			}
+			continue
+		case "allow", "disallow":
+			if group != nil && value != "" {
+				group.rules = append(group.rules, robotsRule{
+					key == "allow", len(value), robotsPattern(value),
+				})
+			}
+		case "crawl-delay":
+			seconds, err := strconv.ParseFloat(value, 64)
+			if group != nil && err == nil && seconds > 0 {
+				group.crawlDelay = time.Duration(seconds * float64(time.Second))
+			}
+		case "sitemap":
+			robots.sitemaps = append(robots.sitemaps, value)
+		}
+		inAgents = false
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return robots, nil
+}
+
+// robotsPattern compiles an Allow or Disallow path, where
+// * matches any characters and a trailing $ ends the path
+func robotsPattern(path string) *regexp.Regexp {
+
+	anchored := strings.HasSuffix(path, "$")
+	path = strings.TrimSuffix(path, "$")
+
+	expr := "^" + strings.Replace(regexp.QuoteMeta(path), `\*`, ".*", -1)
+	if anchored {
+		expr += "$"
+	}
+	return regexp.MustCompile(expr)
+}
+
+// group finds the rules for the user agent, from the group
+// naming its product token or else from the * group
+func (r *robotsFile) group(userAgent string) robotsGroup {
+
+	if r.allowAll {
+		return robotsGroup{}
+	}
+
+	best := r.defaultIdx
+	if agent := productToken(userAgent); agent != "" {
+	groups:
+		for i, group := range r.groups {
+			for _, name := range group.agents {
+				if name == agent {
+					best = i
+					break groups
+				}
+			}
+		}
+	}
+
+	if best < 0 {
+		return robotsGroup{}
+	}
+	return r.groups[best]
+}
+
+// productToken returns the lowercased name of the user agent,
+// before any "/version" or comment, which is all User-agent
+// lines are matched on (RFC 9309 2.2.1)
+func productToken(userAgent string) string {
+	agent := strings.ToLower(strings.TrimSpace(userAgent))
+	if i := strings.IndexAny(agent, "/ "); i >= 0 {
+		agent = agent[:i]
+	}
+	return agent
+}
+
+// allowed applies the longest matching rule
+// to the url, with Allow winning any tie
+func (g robotsGroup) allowed(uri *url.URL) bool {
+
+	path := uri.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if uri.RawQuery != "" {
+		path += "?" + uri.RawQuery
+	}
+
+	allow, length := true, -1
+	for _, rule := range g.rules {
+		if !rule.pattern.MatchString(path) {
+			continue
+		}
+		if rule.length > length || (rule.length == length && rule.allow) {
+			allow, length = rule.allow, rule.length
+		}
+	}
+	return allow
+}
+
+func (e *RobotsError) Error() string {
+	return fmt.Sprintf("Url %q is disallowed by robots.txt", e.URL)
+}
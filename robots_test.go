@@ -0,0 +1,199 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const robotsTestTxt = `
+# Test robots file
+User-agent: *
+Disallow: /private
+Allow: /private/public
+Disallow: /*.pdf$
+
+User-agent: slowbot
+User-agent: otherbot
+Crawl-delay: 0.1
+Disallow: /
+
+Sitemap: http://localhost/sitemap.xml
+Sitemap: http://localhost/news.xml`
+
+type RobotsTest struct {
+	Agent string
+	URL   string
+	Exp   bool
+}
+
+var robotsTests = []RobotsTest{
+	RobotsTest{Agent: "", URL: "http://localhost/", Exp: true},
+	RobotsTest{Agent: "", URL: "http://localhost/private", Exp: false},
+	RobotsTest{Agent: "", URL: "http://localhost/private/page", Exp: false},
+	RobotsTest{Agent: "", URL: "http://localhost/private/public", Exp: true},
+	RobotsTest{Agent: "", URL: "http://localhost/doc.pdf", Exp: false},
+	RobotsTest{Agent: "", URL: "http://localhost/doc.pdf?x=1", Exp: true},
+	RobotsTest{Agent: "MyBot/1.0", URL: "http://localhost/page", Exp: true},
+	RobotsTest{Agent: "SlowBot/2.1", URL: "http://localhost/page", Exp: false},
+	RobotsTest{Agent: "otherbot", URL: "http://localhost/", Exp: false},
+}
+
+func TestRobotsGetter_Allowed(t *testing.T) {
+
+	for _, test := range robotsTests {
+
+		getter := &RobotsGetter{
+			Getter:    MemoryGetter{"http://localhost/robots.txt": robotsTestTxt},
+			UserAgent: test.Agent,
+		}
+
+		allowed, err := getter.Allowed(context.Background(), test.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if allowed != test.Exp {
+			t.Errorf("%q getting %q: expected %v, received %v",
+				test.Agent, test.URL, test.Exp, allowed)
+		}
+	}
+}
+
+func TestRobotsGetter_Get(t *testing.T) {
+
+	requests := 0
+	getter := &RobotsGetter{
+		Getter: getterFunc(func(url string, srcURL string) (io.ReadCloser, error) {
+			requests++
+			return MemoryGetter{
+				"http://localhost/robots.txt": robotsTestTxt,
+				"http://localhost/page":       "page",
+			}.Get(url, srcURL)
+		}),
+	}
+
+	verifyGetter(t, getter, "http://localhost/page", "page")
+	verifyGetter(t, getter, "http://localhost/page", "page")
+
+	_, err := getter.Get("/private", "http://localhost/page")
+	var robotsErr *RobotsError
+	if !errors.As(err, &robotsErr) {
+		t.Fatalf("Expected a RobotsError, received %v", err)
+	}
+	if robotsErr.URL != "http://localhost/private" {
+		t.Fatalf("Expected the resolved url, received %q", robotsErr.URL)
+	}
+
+	// robots.txt is only retrieved once
+	if requests != 3 {
+		t.Fatalf("Expected 3 requests, received %v", requests)
+	}
+}
+
+func TestRobotsGetter_CrawlDelay(t *testing.T) {
+
+	getter := &RobotsGetter{
+		Getter: MemoryGetter{
+			"http://localhost/robots.txt": "User-agent: *\nCrawl-delay: 0.05",
+			"http://localhost/page":       "page",
+		},
+	}
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		verifyGetter(t, getter, "http://localhost/page", "page")
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Fatalf("Expected 3 requests to take at least 100ms, took %v", elapsed)
+	}
+}
+
+func TestRobotsGetter_Missing(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/robots.txt" {
+				http.NotFound(w, r)
+				return
+			}
+			io.WriteString(w, "page")
+		}))
+	defer server.Close()
+
+	getter := &RobotsGetter{Getter: HTTPGetter()}
+	verifyGetter(t, getter, server.URL+"/private", "page")
+
+	// Getters other than HTTP report a missing robots.txt as not found
+	dir := t.TempDir()
+	path, _ := FixturePath("http://localhost/private")
+	os.MkdirAll(filepath.Join(dir, filepath.Dir(path)), 0755)
+	os.WriteFile(filepath.Join(dir, path), []byte("page"), 0644)
+
+	for _, getter := range []Getter{
+		MemoryGetter{"http://localhost/private": "page"},
+		DirGetter(dir),
+	} {
+		verifyGetter(t, &RobotsGetter{Getter: getter}, "http://localhost/private", "page")
+	}
+}
+
+func TestRobotsGetter_Sitemaps(t *testing.T) {
+
+	getter := &RobotsGetter{
+		Getter: MemoryGetter{"http://localhost/robots.txt": robotsTestTxt},
+	}
+
+	sitemaps, err := getter.Sitemaps(context.Background(), "http://localhost/page")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := "http://localhost/sitemap.xml,http://localhost/news.xml"
+	if act := strings.Join(sitemaps, ","); act != exp {
+		t.Fatalf("Expected %q, received %q", exp, act)
+	}
+}
+
+func TestRobotsGetter_ProductTokens(t *testing.T) {
+
+	robots := `
+User-agent: *
+Disallow: /all
+
+User-agent: Go
+Disallow: /go
+
+User-agent: Googlebot/2.1
+Disallow: /googlebot`
+
+	for _, test := range []RobotsTest{
+		RobotsTest{Agent: "Go/1.0", URL: "http://localhost/go", Exp: false},
+		RobotsTest{Agent: "go", URL: "http://localhost/all", Exp: true},
+		RobotsTest{Agent: "Googlebot/2.1", URL: "http://localhost/googlebot", Exp: false},
+		RobotsTest{Agent: "Googlebot/2.1", URL: "http://localhost/go", Exp: true},
+		RobotsTest{Agent: "Googlebot-Scraper", URL: "http://localhost/go", Exp: true},
+		RobotsTest{Agent: "Googlebot-Scraper", URL: "http://localhost/all", Exp: false},
+		RobotsTest{Agent: "Go-http-client/1.1", URL: "http://localhost/all", Exp: false},
+	} {
+		getter := &RobotsGetter{
+			Getter:    MemoryGetter{"http://localhost/robots.txt": robots},
+			UserAgent: test.Agent,
+		}
+
+		allowed, err := getter.Allowed(context.Background(), test.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if allowed != test.Exp {
+			t.Errorf("%q getting %q: expected %v, received %v",
+				test.Agent, test.URL, test.Exp, allowed)
+		}
+	}
+}
@@ -1,18 +1,55 @@
 package scraper
 
-import css "github.com/andybalholm/cascadia"
+import (
+	"context"
+	"sync"
+
+	css "github.com/andybalholm/cascadia"
+)
 
 // Sel (Selector) is a simple key-value map of
 // prop names to values based on a css selector
 type Sel map[string]string
 
+// Options configures a Scraper created by NewWithOptions
+type Options struct {
+	// Logger logs the scraper's progress when not nil
+	Logger Logger
+	// Getter retrieves the pages, defaulting to HTTPGetter()
+	Getter Getter
+	// Limits caps the resources any one page may use
+	Limits Limits
+	// DocumentCache is how many bytes of parsed pages are kept
+	// so pages followed again are not fetched and parsed twice,
+	// zero to keep none
+	DocumentCache int64
+	// Workers is how many pages Follow fetches and
+	// parses at once, defaulting to one at a time
+	Workers int
+	// PerHost limits how many of the pages being
+	// fetched at once are from the same host
+	PerHost int
+	// Canonicalizer decides which urls are for the same
+	// page, defaulting to the one used by CanonicalURL. A
+	// page's <link rel="canonical"> also lets Crawl skip it
+	// as a duplicate and Follows of that url reuse it.
+	Canonicalizer *Canonicalizer
+	// URLKey, when set, is the key each record selected
+	// holds the canonical url of the page it came from under
+	URLKey string
+}
+
 // Scraper defines a simple
 // web scraper's functionality
 type Scraper interface {
 	Filter(selector string) Scraper
 	Select(selector Sel) Scraper
 	Follow(selector string) Scraper
+	Submit(formSelector string, fields map[string]string) Scraper
+	Paginate(nextSelector string, opts PageOptions) Scraper
+	Crawl(linkSelector string, opts CrawlOptions) Scraper
 	Done() ([]map[string]string, error)
+	Stream(ctx context.Context) (<-chan Record, <-chan error)
 }
 
 type initializer interface {
@@ -20,12 +57,36 @@ type initializer interface {
 	init(url string) Scraper
 }
 
+// step is one call made on the Scraper, which is only
+// run on its nodes once the results are asked for
+type step struct {
+	// leads is true for steps that lead to new nodes,
+	// which replace those the step was applied to
+	leads bool
+	// fetches is true for steps that get pages
+	fetches bool
+	apply   func(n node) ([]node, error)
+	// started, when set, is called by run with the
+	// number of nodes the step is about to apply to,
+	// and by Stream with 1 as each node reaches it
+	started func(nodes int)
+}
+
+// applied holds the nodes a step led to from one node
+type applied struct {
+	nodes []node
+	err   error
+}
+
 type scraper struct {
 	Getter
 	nodeFactory
+	Context  context.Context
+	Workers  int
 	Nodes    []node
 	RootNode node
 	Error    error
+	steps    []step
 }
 
 // Get creates a new scraper by
@@ -34,29 +95,61 @@ func Get(url string) Scraper {
 	return New(url, nil, nil)
 }
 
+// GetContext creates a new scraper by retrieving the HTML
+// at the given URL, stopping once the context is done
+func GetContext(ctx context.Context, url string) Scraper {
+	return NewWithContext(ctx, url, nil, nil)
+}
+
 // New creates a new scraper by using
 // the data provided by the specified Getter
 func New(url string, logger Logger, getter Getter) Scraper {
+	return NewWithContext(context.Background(), url, logger, getter)
+}
+
+// NewWithContext creates a new scraper by using the data
+// provided by the specified Getter. Every request made by
+// the scraper, including those made by Follow, is cancelled
+// once the context is done.
+func NewWithContext(ctx context.Context, url string, logger Logger, getter Getter) Scraper {
+	return NewWithOptions(ctx, url, Options{Logger: logger, Getter: getter})
+}
+
+// NewWithOptions creates a new scraper configured by the
+// options, cancelling its requests once the context is done
+func NewWithOptions(ctx context.Context, url string, opts Options) Scraper {
 
+	ctx = withStart(withLimits(ctx, opts.Limits), url)
+	getter, logger := opts.Getter, opts.Logger
 	if getter == nil {
 		getter = HTTPGetter()
 	}
-	var s initializer
 	if logger != nil {
+		logger = &lockedLogger{Logger: logger}
 		getter = &getterLog{logger, getter}
+	}
+	factory := &nFactory{
+		ctx, getter, opts.Limits,
+		newDocumentCache(opts.DocumentCache, opts.Canonicalizer),
+		newFollowPool(opts.Workers, opts.PerHost),
+		opts.Canonicalizer, opts.URLKey,
+	}
+
+	var s initializer
+	if logger != nil {
 		s = &scraperLog{
 			logger,
 			&scraper{
 				getter,
-				nFactoryLog{logger, nFactory{getter}},
-				nil, nil, nil,
+				nFactoryLog{logger, factory},
+				ctx, opts.Workers, nil, nil, nil, nil,
 			},
 		}
 	} else {
 		s = &scraper{
 			getter,
-			nFactory{getter},
-			nil, nil, nil,
+			factory,
+			ctx, opts.Workers, nil, nil, nil, nil,
 		}
 	}
 
@@ -74,14 +167,9 @@ func (s *scraper) Filter(selector string) Scraper {
 		return s.setError(err)
 	}
 
-	var allNodes []node
-	for _, n := range s.Nodes {
-		nodes := n.Filter(selector, sel)
-		allNodes = append(allNodes, nodes...)
-	}
-
-	s.Nodes = allNodes
-	return s
+	return s.addStep(step{true, false, func(n node) ([]node, error) {
+		return n.Filter(selector, sel), nil
+	}, nil})
 }
 
 func (s *scraper) Select(selectors Sel) Scraper {
@@ -89,25 +177,24 @@ func (s *scraper) Select(selectors Sel) Scraper {
 	if s.Error != nil {
 		return s
 	}
-	if selectors == nil {
-		selectors = Sel{}
-	}
 
+	sels := make(map[string]css.Selector, len(selectors))
 	for name, selector := range selectors {
 		sel, err := css.Compile(selector)
 		if err != nil {
 			return s.setError(err)
 		}
+		sels[name] = sel
+	}
 
-		for _, n := range s.Nodes {
-			err = n.Select(name, selector, sel)
-			if err != nil {
-				return s.setError(err)
+	return s.addStep(step{false, false, func(n node) ([]node, error) {
+		for name, selector := range selectors {
+			if err := n.Select(name, selector, sels[name]); err != nil {
+				return nil, err
 			}
 		}
-	}
-
-	return s
+		return nil, nil
+	}, nil})
 }
 
 func (s *scraper) Follow(selector string) Scraper {
@@ -121,27 +208,116 @@ func (s *scraper) Follow(selector string) Scraper {
 		return s.setError(err)
 	}
 
-	var allNodes []node
-	for _, n := range s.Nodes {
-		nodes := n.Follow(selector, sel)
-		allNodes = append(allNodes, nodes...)
+	return s.addStep(step{true, true, func(n node) ([]node, error) {
+		return n.Follow(selector, sel)
+	}, nil})
+}
+
+func (s *scraper) Submit(formSelector string, fields map[string]string) Scraper {
+
+	if s.Error != nil {
+		return s
 	}
 
-	s.Nodes = allNodes
-	return s
+	sel, err := css.Compile(formSelector)
+	if err != nil {
+		return s.setError(err)
+	}
+
+	return s.addStep(step{true, false, func(n node) ([]node, error) {
+		return n.Submit(formSelector, sel, fields)
+	}, nil})
 }
 
 func (s *scraper) Done() ([]map[string]string, error) {
 
-	if s.Error != nil {
-		return nil, s.Error
+	if err := s.run(); err != nil {
+		return nil, err
 	}
 	return s.RootNode.GetData(), nil
 }
 
+func (s *scraper) addStep(st step) Scraper {
+	s.steps = append(s.steps, st)
+	return s
+}
+
+// run takes every node through the steps not yet run, one
+// step at a time, so a step can work on many nodes at once
+func (s *scraper) run() error {
+
+	steps := s.steps
+	s.steps = nil
+
+	for _, st := range steps {
+
+		if s.Error != nil {
+			break
+		}
+		if st.started != nil {
+			st.started(len(s.Nodes))
+		}
+
+		if !st.leads {
+			for _, n := range s.Nodes {
+				if _, err := st.apply(n); err != nil {
+					s.Error = err
+					break
+				}
+			}
+			continue
+		}
+
+		var allNodes []node
+		for _, applied := range s.applyNodes(st) {
+			if applied.err != nil {
+				s.Error = applied.err
+				break
+			}
+			allNodes = append(allNodes, applied.nodes...)
+		}
+		s.Nodes = allNodes
+	}
+
+	return s.Error
+}
+
+// applyNodes applies the step to every node, with up to
+// Workers nodes at once for steps that fetch pages,
+// keeping the results in the order of the nodes
+func (s *scraper) applyNodes(st step) []applied {
+
+	results := make([]applied, len(s.Nodes))
+
+	if !st.fetches || s.Workers <= 1 {
+		for i, n := range s.Nodes {
+			results[i].nodes, results[i].err = st.apply(n)
+			if results[i].err != nil {
+				return results[:i+1]
+			}
+		}
+		return results
+	}
+
+	var wg sync.WaitGroup
+	workers := make(chan struct{}, s.Workers)
+	for i, n := range s.Nodes {
+		wg.Add(1)
+		workers <- struct{}{}
+		go func(i int, n node) {
+			defer wg.Done()
+			results[i].nodes, results[i].err = st.apply(n)
+			<-workers
+		}(i, n)
+	}
+	wg.Wait()
+
+	return results
+}
+
 func (s *scraper) init(url string) Scraper {
 
-	resp, err := s.Get(url, "")
+	resp, err := getContext(s.Context, s.Getter, url, "")
 	if err != nil {
 		return s.setError(err)
 	}
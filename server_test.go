@@ -0,0 +1,24 @@
+package scraper
+
+import (
+	"net/http"
+	"net/http/httptest"
+)
+
+// testRoutes maps the paths a test server serves to their handlers
+type testRoutes map[string]http.HandlerFunc
+
+// newTestServer serves each path from its route,
+// and a 404 for the paths without one
+func newTestServer(routes testRoutes) *httptest.Server {
+
+	return httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			route, ok := routes[r.URL.Path]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			route(w, r)
+		}))
+}
@@ -0,0 +1,128 @@
+package scraper
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// Session is a cookie jar that remembers the cookies set
+// while scraping so they can be saved to a file and loaded
+// by a later run. Share one Session between HTTPGetters (or
+// reuse the getter) to carry a login across New calls.
+type Session struct {
+	jar     *cookiejar.Jar
+	mu      sync.Mutex
+	cookies map[string]sessionCookie
+}
+
+type sessionCookie struct {
+	URL    string
+	Cookie *http.Cookie
+}
+
+// NewSession creates an empty Session
+func NewSession() *Session {
+
+	// cookiejar.New only fails when given bad options
+	jar, _ := cookiejar.New(&cookiejar.Options{
+		PublicSuffixList: publicsuffix.List,
+	})
+
+	return &Session{jar: jar, cookies: make(map[string]sessionCookie)}
+}
+
+// LoadSession creates a Session holding
+// the cookies saved to the file at path
+func LoadSession(path string) (*Session, error) {
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var saved []sessionCookie
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, err
+	}
+
+	s := NewSession()
+	for _, c := range saved {
+		u, err := url.Parse(c.URL)
+		if err != nil {
+			return nil, err
+		}
+		s.SetCookies(u, []*http.Cookie{c.Cookie})
+	}
+
+	return s, nil
+}
+
+// HTTPCookieJar makes the Getter store the cookies servers set
+// and send them back with later requests, such as those made by
+// Follow. Pass a Session for cookies that can be saved.
+func HTTPCookieJar(jar http.CookieJar) HTTPOption {
+	return func(cfg *httpConfig) {
+		cfg.jar = jar
+	}
+}
+
+// Save writes the session's unexpired cookies to the file at path
+func (s *Session) Save(path string) error {
+
+	s.mu.Lock()
+	now := time.Now()
+	saved := make([]sessionCookie, 0, len(s.cookies))
+	for _, c := range s.cookies {
+		if c.Cookie.Expires.IsZero() || c.Cookie.Expires.After(now) {
+			saved = append(saved, c)
+		}
+	}
+	s.mu.Unlock()
+
+	data, err := json.MarshalIndent(saved, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return writeFile(path, data)
+}
+
+// SetCookies implements http.CookieJar
+func (s *Session) SetCookies(u *url.URL, cookies []*http.Cookie) {
+
+	s.jar.SetCookies(u, cookies)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, cookie := range cookies {
+
+		c := *cookie
+		// Saved cookies outlive this run, so
+		// fix MaxAge to an absolute expiry
+		if c.MaxAge > 0 {
+			c.Expires = time.Now().Add(time.Duration(c.MaxAge) * time.Second)
+			c.MaxAge = 0
+		}
+
+		key := strings.ToLower(u.Hostname()+";"+c.Domain+";"+c.Path) + ";" + c.Name
+		if c.MaxAge < 0 || (!c.Expires.IsZero() && c.Expires.Before(time.Now())) {
+			delete(s.cookies, key)
+			continue
+		}
+		s.cookies[key] = sessionCookie{u.String(), &c}
+	}
+}
+
+// Cookies implements http.CookieJar
+func (s *Session) Cookies(u *url.URL) []*http.Cookie {
+	return s.jar.Cookies(u)
+}
@@ -0,0 +1,85 @@
+package scraper
+
+import (
+	"io"
+	"net/http"
+	"path/filepath"
+	"testing"
+)
+
+var sessionTestRoutes = testRoutes{
+	"/login": func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{
+			Name: "session", Value: "secret", Path: "/", MaxAge: 3600,
+		})
+		io.WriteString(w, `<a href="/private">Private</a>`)
+	},
+	"/private": func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie("session")
+		if err != nil || cookie.Value != "secret" {
+			http.Error(w, "login required", http.StatusForbidden)
+			return
+		}
+		io.WriteString(w, "<p>private data</p>")
+	},
+}
+
+func TestSession_Follow(t *testing.T) {
+
+	server := newTestServer(sessionTestRoutes)
+	defer server.Close()
+
+	getter := HTTPGetter(HTTPCookieJar(NewSession()))
+	results, err := New(server.URL+"/login", nil, getter).
+		Follow("a[href]").
+		Select(Sel{"value": "p"}).
+		Done()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0]["value"] != "private data" {
+		t.Fatalf("Expected the private page, received %v", results)
+	}
+
+	// The same getter stays logged in for later scrapes
+	results, err = New(server.URL+"/private", nil, getter).
+		Select(Sel{"value": "p"}).
+		Done()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0]["value"] != "private data" {
+		t.Fatalf("Expected the private page, received %v", results)
+	}
+}
+
+func TestSession_SaveLoad(t *testing.T) {
+
+	server := newTestServer(sessionTestRoutes)
+	defer server.Close()
+
+	session := NewSession()
+	if _, err := New(server.URL+"/login", nil,
+		HTTPGetter(HTTPCookieJar(session))).Done(); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "session.json")
+	if err := session.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadSession(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	getter := HTTPGetter(HTTPCookieJar(loaded))
+	verifyGetter(t, getter, server.URL+"/private", "<p>private data</p>")
+
+	_, err = HTTPGetter(HTTPCookieJar(NewSession())).Get(server.URL+"/private", "")
+	if statusErr, ok := err.(*HTTPStatusError); !ok ||
+		statusErr.StatusCode != http.StatusForbidden {
+		t.Fatalf("Expected a 403 HTTPStatusError, received %v", err)
+	}
+}
@@ -0,0 +1,107 @@
+package scraper
+
+import "context"
+
+// Record is the data selected
+// from one element of a page
+type Record map[string]string
+
+// Stream runs the scrape, sending each record as soon as the
+// page it is from has been through every step, in the same
+// order as Done. Pages are scraped depth first, one record
+// at a time, so the pages already streamed can be freed
+// and a slow reader slows the scrape down. Both channels
+// are closed once the scrape ends, with any error sent on
+// the error channel first. The requests use the context
+// the scraper was created with, and cancelling either it
+// or the context given stops the stream. Callers must
+// read every record or cancel one of the contexts, as
+// otherwise the scrape waits for the next read forever.
+func (s *scraper) Stream(ctx context.Context) (<-chan Record, <-chan error) {
+
+	records, errs := make(chan Record), make(chan error, 1)
+
+	go func() {
+		defer close(errs)
+		defer close(records)
+
+		err := s.Error
+		steps := s.steps
+		s.steps = nil
+
+		for _, n := range s.Nodes {
+			if err != nil {
+				break
+			}
+			err = s.stream(ctx, n, steps, records)
+		}
+		if err != nil {
+			s.Error = err
+			errs <- err
+		}
+	}()
+
+	return records, errs
+}
+
+// stream takes the node through the steps, sending its
+// own records once no later step can change them before
+// streaming each of the nodes it leads to in turn
+func (s *scraper) stream(ctx context.Context, n node, steps []step, out chan<- Record) error {
+
+	for i, st := range steps {
+
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := s.Context.Err(); err != nil {
+			return err
+		}
+		if st.started != nil {
+			st.started(1)
+		}
+
+		if !st.leads {
+			if _, err := st.apply(n); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := s.sendRecords(ctx, out, n.OwnData()); err != nil {
+			return err
+		}
+
+		nodes, err := st.apply(n)
+		if err != nil {
+			return err
+		}
+		n.DropNodes()
+
+		for j, next := range nodes {
+			if err := s.stream(ctx, next, steps[i+1:], out); err != nil {
+				return err
+			}
+			nodes[j] = nil
+		}
+		return nil
+	}
+
+	return s.sendRecords(ctx, out, n.GetData())
+}
+
+// sendRecords sends the records, giving up once either
+// the stream's or the scraper's context is done
+func (s *scraper) sendRecords(ctx context.Context, out chan<- Record, data []map[string]string) error {
+
+	for _, d := range data {
+		select {
+		case out <- d:
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-s.Context.Done():
+			return s.Context.Err()
+		}
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func collectStream(records <-chan Record, errs <-chan error) ([]map[string]string, error) {
+
+	var results []map[string]string
+	for record := range records {
+		results = append(results, record)
+	}
+	return results, <-errs
+}
+
+func TestStream_MatchesDone(t *testing.T) {
+
+	server := newTestServer(harTestRoutes)
+	defer server.Close()
+
+	scrape := func() Scraper {
+		return New(server.URL, nil, HTTPGetter()).
+			Select(Sel{"links": "a"}).
+			Follow("a[href]").
+			Select(Sel{"value": "p"})
+	}
+
+	expected, err := scrape().Done()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := collectStream(scrape().Stream(context.Background()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(results, expected) {
+		t.Fatalf("Expected %v, received %v", expected, results)
+	}
+}
+
+func TestStream_Early(t *testing.T) {
+
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/":
+				io.WriteString(w, pageLinks("", 5))
+			case "/4":
+				// The last page waits for the first record
+				select {
+				case <-release:
+				case <-time.After(5 * time.Second):
+				}
+				io.WriteString(w, "<p>4</p>")
+			default:
+				io.WriteString(w, "<p>"+r.URL.Path[1:]+"</p>")
+			}
+		}))
+	defer server.Close()
+
+	records, errs := New(server.URL, nil, HTTPGetter()).
+		Filter("div").
+		Follow("a[href]").
+		Select(Sel{"value": "p"}).
+		Stream(context.Background())
+
+	first := <-records
+	close(release)
+	if first["value"] != "0" {
+		t.Fatalf("Expected the first page's record, received %v", first)
+	}
+
+	results, err := collectStream(records, errs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifyPageOrder(t, append([]map[string]string{first}, results...), 5)
+}
+
+func TestStream_Error(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/":
+				io.WriteString(w, `<div><a href="/ok">ok</a></div><div><a href="/fail">fail</a></div>`)
+			case "/ok":
+				io.WriteString(w, "<p>ok</p>")
+			default:
+				http.Error(w, "failed", http.StatusInternalServerError)
+			}
+		}))
+	defer server.Close()
+
+	getter := HTTPGetter(HTTPStatusPolicy(func(status int) StatusAction {
+		if status == http.StatusOK {
+			return StatusAccept
+		}
+		return StatusFail
+	}))
+
+	results, err := collectStream(New(server.URL, nil, getter).
+		Filter("div").
+		Follow("a[href]").
+		Select(Sel{"value": "p"}).
+		Stream(context.Background()))
+
+	var statusErr *HTTPStatusError
+	if !errors.As(err, &statusErr) {
+		t.Fatalf("Expected an HTTPStatusError, received %v", err)
+	}
+	if len(results) != 1 || results[0]["value"] != "ok" {
+		t.Fatalf("Expected the record streamed before the error, received %v", results)
+	}
+}
+
+func TestStream_Cancel(t *testing.T) {
+
+	server := newConcurrencyServer(pageLinks("", 5))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	records, errs := New(server.URL, nil, HTTPGetter()).
+		Filter("div").
+		Follow("a[href]").
+		Select(Sel{"value": "p"}).
+		Stream(ctx)
+
+	<-records
+	cancel()
+
+	_, err := collectStream(records, errs)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected the stream to be cancelled, received %v", err)
+	}
+}
+
+func TestStream_ScraperCancel(t *testing.T) {
+
+	server := newConcurrencyServer(pageLinks("", 5))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	records, errs := NewWithOptions(ctx, server.URL, Options{}).
+		Filter("div").
+		Follow("a[href]").
+		Select(Sel{"value": "p"}).
+		Stream(context.Background())
+
+	// The reader stops reading, but the scraper's context
+	// still stops the stream rather than leaving it blocked
+	<-records
+	cancel()
+
+	if err := <-errs; !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected the stream to be cancelled, received %v", err)
+	}
+}
@@ -0,0 +1,529 @@
+package scraper
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultWARCSize is the size a WARC file is
+// allowed to grow to before starting another
+const defaultWARCSize = 1 << 30
+
+// ArchivingGetter is a Getter that writes every response of
+// the Getter it wraps, along with the request that was sent,
+// to gzipped WARC 1.1 files so a scrape can be archived and
+// later replayed with a WARCGetter. Responses from an
+// HTTPGetter are archived as they came over the wire, still
+// compressed and with the headers actually sent, except that
+// an Authorization header is redacted. Other Getters, or
+// responses served by a cache in between, are archived as
+// the wrapped Getter returns them. An ArchivingGetter
+// must be closed when done.
+type ArchivingGetter struct {
+	Getter
+	// Dir is the directory the WARC files are written to
+	Dir string
+	// Prefix starts the name of each file,
+	// defaulting to "scrape"
+	Prefix string
+	// MaxSize is the size in bytes a file grows to before
+	// another is started, defaulting to 1GB
+	MaxSize int64
+
+	mu     sync.Mutex
+	file   *os.File
+	size   int64
+	serial int
+}
+
+// WARCGetter is a Getter that serves the responses archived in
+// WARC files, such as those written by an ArchivingGetter. When
+// a url was archived more than once its latest response is used.
+type WARCGetter struct {
+	// StatusPolicy decides which archived statuses are
+	// returned as pages, defaulting to DefaultStatusPolicy
+	StatusPolicy StatusPolicy
+
+	responses map[string]*warcRecord
+}
+
+type warcRecord struct {
+	header http.Header
+	block  []byte
+}
+
+// warcWire is filled in by the HTTPGetter with the request it
+// sent and the response as it was received, before any gzip
+// was undone, for the ArchivingGetter to store
+type warcWire struct {
+	request []byte
+	status  int
+	header  http.Header
+	body    bytes.Buffer
+}
+
+type warcWireKey struct{}
+
+// Get retrieves the url and archives it
+func (g *ArchivingGetter) Get(url string, srcURL string) (io.ReadCloser, error) {
+	return g.GetContext(context.Background(), url, srcURL)
+}
+
+// GetContext retrieves the url with the wrapped Getter
+// and archives the request and its response
+func (g *ArchivingGetter) GetContext(ctx context.Context, url string, srcURL string) (io.ReadCloser, error) {
+
+	resolved, err := resolveURL(url, srcURL)
+	if err != nil {
+		return nil, err
+	}
+
+	req := requestFrom(ctx)
+	if req.Referer == "" {
+		req.Referer = srcURL
+	}
+	req.URL = resolved
+
+	wire := &warcWire{}
+	rc, err := getContext(context.WithValue(ctx, warcWireKey{}, wire), g.Getter, url, srcURL)
+
+	// Unaccepted statuses are archived too, although
+	// only the start of their body was kept
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		truncated := len(statusErr.Body) >= maxStatusErrorBody
+		if err := g.archive(&req, wire, statusErr.StatusCode,
+			statusErr.Header, statusErr.Body, truncated); err != nil {
+			return nil, err
+		}
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	defer rc.Close()
+	body, err := ioutil.ReadAll(limitBody(ctx, resolved, rc))
+	if err != nil {
+		return nil, err
+	}
+
+	status := http.StatusOK
+	if resp, ok := rc.(Response); ok {
+		status = resp.StatusCode()
+	}
+	header := responseHeader(rc)
+
+	if err := g.archive(&req, wire, status, header, body, false); err != nil {
+		return nil, err
+	}
+
+	return &memoryResponse{bytes.NewReader(body), status, header}, nil
+}
+
+// Close closes the WARC file being written
+func (g *ArchivingGetter) Close() error {
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.file == nil {
+		return nil
+	}
+	err := g.file.Close()
+	g.file = nil
+	return err
+}
+
+// archive writes a response record and the request record
+// concurrent to it, using the wire's copy of both when the
+// response came from an HTTPGetter
+func (g *ArchivingGetter) archive(req *Request, wire *warcWire, status int,
+	header http.Header, body []byte, truncated bool) error {
+
+	requestBlock := wire.request
+	if requestBlock != nil && wire.status == status {
+		header, body = wire.header, wire.body.Bytes()
+	} else {
+		var err error
+		if requestBlock, err = warcRequestBlock(req); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now().UTC()
+	responseID, err := warcRecordID()
+	if err != nil {
+		return err
+	}
+	requestID, err := warcRecordID()
+	if err != nil {
+		return err
+	}
+
+	var block bytes.Buffer
+	fmt.Fprintf(&block, "HTTP/1.1 %d %s\r\n", status, http.StatusText(status))
+	header.Write(&block)
+	block.WriteString("\r\n")
+	block.Write(body)
+
+	response := warcHeader("response", responseID, req.URL, now)
+	response.Set("Content-Type", "application/http;msgtype=response")
+	setWARCField(response, "WARC-Concurrent-To", requestID)
+	setWARCField(response, "WARC-Payload-Digest", warcDigest(body))
+	if truncated {
+		setWARCField(response, "WARC-Truncated", "length")
+	}
+
+	request := warcHeader("request", requestID, req.URL, now)
+	request.Set("Content-Type", "application/http;msgtype=request")
+	setWARCField(request, "WARC-Concurrent-To", responseID)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if err := g.rotate(now); err != nil {
+		return err
+	}
+	if err := g.write(response, block.Bytes()); err != nil {
+		return err
+	}
+	return g.write(request, requestBlock)
+}
+
+// rotate opens the next WARC file, starting it with a
+// warcinfo record, when none is open or the current
+// one has grown past MaxSize
+func (g *ArchivingGetter) rotate(now time.Time) error {
+
+	max := g.MaxSize
+	if max <= 0 {
+		max = defaultWARCSize
+	}
+	if g.file != nil && g.size < max {
+		return nil
+	}
+
+	if g.file != nil {
+		if err := g.file.Close(); err != nil {
+			return err
+		}
+		g.file = nil
+	}
+
+	if err := os.MkdirAll(g.Dir, 0755); err != nil {
+		return err
+	}
+
+	prefix := g.Prefix
+	if prefix == "" {
+		prefix = "scrape"
+	}
+	g.serial++
+	name := fmt.Sprintf("%s-%s-%05d.warc.gz",
+		prefix, now.Format("20060102150405"), g.serial)
+
+	file, err := os.OpenFile(filepath.Join(g.Dir, name),
+		os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	g.file, g.size = file, 0
+
+	id, err := warcRecordID()
+	if err != nil {
+		return err
+	}
+	info := warcHeader("warcinfo", id, "", now)
+	setWARCField(info, "WARC-Filename", name)
+	info.Set("Content-Type", "application/warc-fields")
+	return g.write(info, []byte("software: scraper\r\nformat: WARC File Format 1.1\r\n"))
+}
+
+// write appends a record to the current file
+// as a gzip member of its own
+func (g *ArchivingGetter) write(header http.Header, block []byte) error {
+
+	setWARCField(header, "WARC-Block-Digest", warcDigest(block))
+	header.Set("Content-Length", strconv.Itoa(len(block)))
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	io.WriteString(zw, "WARC/1.1\r\n")
+	header.Write(zw)
+	io.WriteString(zw, "\r\n")
+	zw.Write(block)
+	io.WriteString(zw, "\r\n\r\n")
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	n, err := g.file.Write(buf.Bytes())
+	g.size += int64(n)
+	return err
+}
+
+// LoadWARC creates a WARCGetter serving the responses in
+// the WARC files at the paths, which may be gzipped or not
+func LoadWARC(paths ...string) (*WARCGetter, error) {
+
+	g := &WARCGetter{responses: make(map[string]*warcRecord)}
+	for _, path := range paths {
+		if err := g.load(path); err != nil {
+			return nil, err
+		}
+	}
+	return g, nil
+}
+
+func (g *WARCGetter) load(path string) error {
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	r, _, err := decompress(file, "")
+	if err != nil {
+		return err
+	}
+
+	br := bufio.NewReader(r)
+	for {
+		record, err := readWARCRecord(br)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("Reading WARC file %q: %w", path, err)
+		}
+		if record.header.Get("WARC-Type") == "response" {
+			g.responses[record.header.Get("WARC-Target-URI")] = record
+		}
+	}
+}
+
+// Get looks up the archived response for the url
+func (g *WARCGetter) Get(url string, srcURL string) (io.ReadCloser, error) {
+	return g.GetContext(context.Background(), url, srcURL)
+}
+
+// GetContext looks up the archived response for
+// the url, failing if the context is already done
+func (g *WARCGetter) GetContext(ctx context.Context, url string, srcURL string) (io.ReadCloser, error) {
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	resolved, err := resolveURL(url, srcURL)
+	if err != nil {
+		return nil, err
+	}
+
+	record := g.responses[resolved]
+	if record == nil {
+		return nil, notFoundError("No WARC record provided for url: " + resolved)
+	}
+
+	resp, err := http.ReadResponse(
+		bufio.NewReader(bytes.NewReader(record.block)), nil)
+	if err != nil {
+		return nil, err
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := g.StatusPolicy
+	if policy == nil {
+		policy = DefaultStatusPolicy
+	}
+	if action := policy(resp.StatusCode); action != StatusAccept {
+		if len(body) > maxStatusErrorBody {
+			body = body[:maxStatusErrorBody]
+		}
+		return nil, &HTTPStatusError{resolved, resp.StatusCode, resp.Header, body, action}
+	}
+
+	return &memoryResponse{bytes.NewReader(body), resp.StatusCode, resp.Header}, nil
+}
+
+// readWARCRecord reads the next record, returning
+// io.EOF when there are no more
+func readWARCRecord(r *bufio.Reader) (*warcRecord, error) {
+
+	version, err := r.ReadString('\n')
+	for err == nil && strings.TrimSpace(version) == "" {
+		version, err = r.ReadString('\n')
+	}
+	if err != nil {
+		if err == io.EOF && strings.TrimSpace(version) == "" {
+			return nil, io.EOF
+		}
+		return nil, err
+	}
+	if !strings.HasPrefix(version, "WARC/") {
+		return nil, fmt.Errorf("Expected a WARC record, found %q", strings.TrimSpace(version))
+	}
+
+	header := http.Header{}
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			break
+		}
+		i := strings.Index(line, ":")
+		if i < 0 {
+			return nil, fmt.Errorf("Invalid WARC header %q", line)
+		}
+		header.Add(strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+1:]))
+	}
+
+	length, err := strconv.ParseInt(header.Get("Content-Length"), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid WARC Content-Length: %w", err)
+	}
+
+	block := make([]byte, length)
+	if _, err := io.ReadFull(r, block); err != nil {
+		return nil, err
+	}
+	return &warcRecord{header, block}, nil
+}
+
+func warcHeader(recordType string, id string, target string, date time.Time) http.Header {
+
+	header := http.Header{}
+	setWARCField(header, "WARC-Type", recordType)
+	setWARCField(header, "WARC-Record-ID", id)
+	setWARCField(header, "WARC-Date", date.Format(time.RFC3339Nano))
+	if target != "" {
+		setWARCField(header, "WARC-Target-URI", target)
+	}
+	return header
+}
+
+// warcRequestBlock rebuilds the HTTP request that was sent,
+// as far as the headers added by the Getters are known
+func warcRequestBlock(req *Request) ([]byte, error) {
+
+	uri, err := url.Parse(req.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	method := req.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	header := req.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	if req.Referer != "" {
+		header.Set("Referer", req.Referer)
+	}
+
+	return httpRequestBlock(method, uri, uri.Host, header, req.Body), nil
+}
+
+// httpRequestBlock writes out an HTTP/1.1 request
+func httpRequestBlock(method string, uri *url.URL, host string,
+	header http.Header, body []byte) []byte {
+
+	var block bytes.Buffer
+	fmt.Fprintf(&block, "%s %s HTTP/1.1\r\nHost: %s\r\n", method, uri.RequestURI(), host)
+	header.Write(&block)
+	block.WriteString("\r\n")
+	block.Write(body)
+	return block.Bytes()
+}
+
+// captureWARCWire copies the request sent and the response
+// received to the context's warcWire, if it has one, returning
+// the body to read in place of the response's so the wire
+// keeps the bytes received
+func captureWARCWire(ctx context.Context, resp *http.Response) io.ReadCloser {
+
+	wire, _ := ctx.Value(warcWireKey{}).(*warcWire)
+	if wire == nil {
+		return resp.Body
+	}
+
+	sent := resp.Request
+	header := sent.Header.Clone()
+	if header.Get("Authorization") != "" {
+		header.Set("Authorization", "REDACTED")
+	}
+	var body []byte
+	if sent.GetBody != nil {
+		if rc, err := sent.GetBody(); err == nil {
+			body, _ = ioutil.ReadAll(rc)
+		}
+	}
+	host := sent.Host
+	if host == "" {
+		host = sent.URL.Host
+	}
+
+	// A retried request replaces what an earlier attempt received
+	wire.request = httpRequestBlock(sent.Method, sent.URL, host, header, body)
+	wire.status = resp.StatusCode
+	wire.header = resp.Header.Clone()
+	wire.body.Reset()
+
+	return struct {
+		io.Reader
+		io.Closer
+	}{io.TeeReader(resp.Body, &wire.body), resp.Body}
+}
+
+// setWARCField sets a WARC header field keeping the case
+// of its name, which http.Header would otherwise change
+func setWARCField(header http.Header, name string, value string) {
+	header[name] = []string{value}
+}
+
+func warcDigest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + base32.StdEncoding.EncodeToString(sum[:])
+}
+
+// warcRecordID returns a random (version 4) UUID URN
+func warcRecordID() (string, error) {
+
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return "", err
+	}
+	id[6] = id[6]&0x0f | 0x40
+	id[8] = id[8]&0x3f | 0x80
+
+	return fmt.Sprintf("<urn:uuid:%x-%x-%x-%x-%x>",
+		id[0:4], id[4:6], id[6:8], id[8:10], id[10:16]), nil
+}
@@ -0,0 +1,180 @@
+package scraper
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestWARC_ArchiveReplay(t *testing.T) {
+
+	server := newTestServer(harTestRoutes)
+	dir := t.TempDir()
+
+	archiver := &ArchivingGetter{Getter: HTTPGetter(), Dir: dir, MaxSize: 1}
+	archived, err := New(server.URL, nil, archiver).
+		Follow("a[href]").
+		Select(Sel{"value": "p"}).
+		Done()
+	server.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := archiver.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Every page goes in a new file once MaxSize is passed
+	paths, err := filepath.Glob(filepath.Join(dir, "scrape-*.warc.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 4 {
+		t.Fatalf("Expected 4 WARC files, received %v", paths)
+	}
+
+	replayer, err := LoadWARC(paths...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayed, err := New(server.URL, nil, replayer).
+		Follow("a[href]").
+		Select(Sel{"value": "p"}).
+		Done()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(archived, replayed) {
+		t.Fatalf("Expected %v to be replayed, received %v", archived, replayed)
+	}
+
+	_, err = replayer.Get(server.URL+"/missing", "")
+	var statusErr *HTTPStatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound {
+		t.Fatalf("Expected the archived 404, received %v", err)
+	}
+}
+
+func TestWARC_Records(t *testing.T) {
+
+	server := newTestServer(harTestRoutes)
+	defer server.Close()
+	dir := t.TempDir()
+
+	archiver := &ArchivingGetter{Getter: HTTPGetter(), Dir: dir, Prefix: "test"}
+	rc, err := archiver.Get(server.URL+"/one", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rc.Close()
+	archiver.Close()
+
+	paths, _ := filepath.Glob(filepath.Join(dir, "test-*.warc.gz"))
+	if len(paths) != 1 {
+		t.Fatalf("Expected 1 WARC file, received %v", paths)
+	}
+
+	file, err := os.Open(paths[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	r, _, err := decompress(file, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var types []string
+	var records []*warcRecord
+	br := bufio.NewReader(r)
+	for {
+		record, err := readWARCRecord(br)
+		if err != nil {
+			break
+		}
+		types = append(types, record.header.Get("WARC-Type"))
+		records = append(records, record)
+	}
+
+	if !reflect.DeepEqual(types, []string{"warcinfo", "response", "request"}) {
+		t.Fatalf("Unexpected records %v", types)
+	}
+
+	response, request := records[1].header, records[2].header
+	if response.Get("WARC-Concurrent-To") != request.Get("WARC-Record-ID") ||
+		request.Get("WARC-Concurrent-To") != response.Get("WARC-Record-ID") {
+		t.Errorf("Expected the request and response to refer to each other")
+	}
+	if digest := warcDigest([]byte("<p>one</p>")); response.Get("WARC-Payload-Digest") != digest {
+		t.Errorf("Expected payload digest %v, received %v",
+			digest, response.Get("WARC-Payload-Digest"))
+	}
+	if digest := warcDigest(records[1].block); response.Get("WARC-Block-Digest") != digest {
+		t.Errorf("Expected block digest %v, received %v",
+			digest, response.Get("WARC-Block-Digest"))
+	}
+}
+
+func TestWARC_Wire(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			writeGzip(w, "<p>compressed</p>")
+		}))
+	defer server.Close()
+	dir := t.TempDir()
+
+	archiver := &ArchivingGetter{
+		Getter: HTTPGetter(HTTPBearerToken("secret")),
+		Dir:    dir,
+	}
+	archived, err := New(server.URL, nil, archiver).Select(Sel{"value": "p"}).Done()
+	if err != nil {
+		t.Fatal(err)
+	}
+	archiver.Close()
+
+	paths, _ := filepath.Glob(filepath.Join(dir, "scrape-*.warc.gz"))
+	replayer, err := LoadWARC(paths...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The body is archived as it was sent, still gzipped
+	record := replayer.responses[server.URL]
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(record.block)), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	if resp.Header.Get("Content-Encoding") != "gzip" ||
+		record.header.Get("WARC-Payload-Digest") != warcDigest(body) {
+		t.Errorf("Expected the gzipped body to be archived, received %q", record.block)
+	}
+
+	// As are the headers sent, without the credentials
+	data, _ := ioutil.ReadFile(paths[0])
+	zr, _ := gzip.NewReader(bytes.NewReader(data))
+	archive, _ := ioutil.ReadAll(zr)
+	if !bytes.Contains(archive, []byte("Accept-Encoding: gzip")) ||
+		!bytes.Contains(archive, []byte("Authorization: REDACTED")) ||
+		bytes.Contains(archive, []byte("secret")) {
+		t.Errorf("Expected the request headers sent, received %q", archive)
+	}
+
+	replayed, err := New(server.URL, nil, replayer).Select(Sel{"value": "p"}).Done()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(archived, replayed) {
+		t.Fatalf("Expected %v to be replayed, received %v", archived, replayed)
+	}
+}